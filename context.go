@@ -0,0 +1,72 @@
+package radix
+
+import (
+	"context"
+)
+
+// WalkErrFn is used when walking the tree with WalkContext. Takes
+// a key and value, returning a non-nil error to terminate the walk.
+type WalkErrFn func(s string, v interface{}) error
+
+// ctxCheckInterval is how many nodes are visited between checks
+// of the context during a walk
+const ctxCheckInterval = 256
+
+// WalkContext is used to walk the tree under a prefix until ctx is
+// done, see Tree.WalkContext. The read lock is released as soon as
+// the walk is cancelled.
+func (t *ConcurrentTree) WalkContext(ctx context.Context, prefix string, fn WalkErrFn) error {
+	t.RLock()
+	defer t.RUnlock()
+	return t.Tree.WalkContext(ctx, prefix, fn)
+}
+
+// WalkContext is used to walk the tree under a prefix, checking
+// periodically if ctx is done. Returns ctx.Err() if the walk was
+// cancelled, or the first non-nil error returned by fn, which
+// also terminates the walk.
+func (t *Tree) WalkContext(ctx context.Context, prefix string, fn WalkErrFn) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	n, _ := t.findPrefix(prefix)
+	if n == nil {
+		return nil
+	}
+	w := ctxWalker{ctx: ctx, fn: fn}
+	return w.walk(n)
+}
+
+// ctxWalker holds the state of a WalkContext walk
+type ctxWalker struct {
+	ctx     context.Context
+	fn      WalkErrFn
+	visited int
+}
+
+// walk is used to do a pre-order walk of a node recursively
+func (w *ctxWalker) walk(n *node) error {
+	w.visited++
+	if w.visited%ctxCheckInterval == 0 {
+		select {
+		case <-w.ctx.Done():
+			return w.ctx.Err()
+		default:
+		}
+	}
+
+	// Visit the leaf values if any
+	if n.leaf != nil {
+		if err := w.fn(n.leaf.key, n.leaf.val); err != nil {
+			return err
+		}
+	}
+
+	// Recurse on the children
+	for _, e := range n.edges {
+		if err := w.walk(e.node); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package radix
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestWalkContext(t *testing.T) {
+	r := NewConcurrentTree()
+	for i := 0; i < 10000; i++ {
+		r.Insert(fmt.Sprintf("key/%05d", i), i)
+	}
+	r.Insert("other", -1)
+
+	// A full walk under a prefix
+	num := 0
+	err := r.WalkContext(context.Background(), "key/", func(k string, v interface{}) error {
+		num++
+		return nil
+	})
+	if err != nil || num != 10000 {
+		t.Fatalf("bad walk: %v %v", num, err)
+	}
+
+	// Errors from the callback are returned
+	errStop := errors.New("stop")
+	num = 0
+	err = r.WalkContext(context.Background(), "", func(k string, v interface{}) error {
+		num++
+		if k == "key/00009" {
+			return errStop
+		}
+		return nil
+	})
+	if err != errStop || num != 10 {
+		t.Fatalf("bad walk: %v %v", num, err)
+	}
+
+	// Cancelling stops the walk shortly after
+	ctx, cancel := context.WithCancel(context.Background())
+	num = 0
+	err = r.WalkContext(ctx, "", func(k string, v interface{}) error {
+		num++
+		if num == 100 {
+			cancel()
+		}
+		return nil
+	})
+	if err != context.Canceled {
+		t.Fatalf("expected cancel: %v", err)
+	}
+	if num >= 100+ctxCheckInterval {
+		t.Fatalf("walk not stopped: %v", num)
+	}
+
+	// A done context is checked up front
+	err = r.WalkContext(ctx, "", func(k string, v interface{}) error {
+		t.Fatalf("should not be called")
+		return nil
+	})
+	if err != context.Canceled {
+		t.Fatalf("expected cancel: %v", err)
+	}
+}
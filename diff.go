@@ -0,0 +1,181 @@
+package radix
+
+import (
+	"reflect"
+)
+
+// DiffKind is used to describe how a key differs between two trees
+type DiffKind int
+
+const (
+	// DiffAdded is used for a key only present in the second tree
+	DiffAdded DiffKind = iota
+
+	// DiffRemoved is used for a key only present in the first tree
+	DiffRemoved
+
+	// DiffChanged is used for a key present in both trees
+	// with values that are not equal
+	DiffChanged
+)
+
+func (k DiffKind) String() string {
+	switch k {
+	case DiffAdded:
+		return "added"
+	case DiffRemoved:
+		return "removed"
+	case DiffChanged:
+		return "changed"
+	}
+	return "unknown"
+}
+
+// DiffEntry describes a single key that differs between two trees.
+// Old is the value in the first tree and New the value in the
+// second, either is nil when the key is missing from that tree.
+type DiffEntry struct {
+	Kind DiffKind
+	Key  string
+	Old  interface{}
+	New  interface{}
+}
+
+// Equal is used to check if two trees hold the same keys with
+// equal values. Values are compared with eq, or reflect.DeepEqual
+// if eq is nil.
+func Equal(a, b *Tree, eq func(x, y interface{}) bool) bool {
+	if a.size != b.size {
+		return false
+	}
+	same := true
+	walkDiff(a, b, eq, func(DiffEntry) bool {
+		same = false
+		return true
+	})
+	return same
+}
+
+// Diff is used to find the keys that were added, removed or changed
+// going from tree a to tree b, returned in sorted key order. Values
+// are compared with eq, or reflect.DeepEqual if eq is nil. Both trees
+// are walked in lockstep, comparing matching nodes directly.
+func Diff(a, b *Tree, eq func(x, y interface{}) bool) []DiffEntry {
+	var out []DiffEntry
+	walkDiff(a, b, eq, func(d DiffEntry) bool {
+		out = append(out, d)
+		return false
+	})
+	return out
+}
+
+// walkDiff calls fn for every difference between a and b in
+// sorted key order, stopping early if fn returns true
+func walkDiff(a, b *Tree, eq func(x, y interface{}) bool, fn func(DiffEntry) bool) {
+	if eq == nil {
+		eq = reflect.DeepEqual
+	}
+	walkLockstep(a.root, b.root, func(la, lb *leafNode) bool {
+		var d DiffEntry
+		switch {
+		case la != nil && lb != nil:
+			if eq(la.val, lb.val) {
+				return false
+			}
+			d = DiffEntry{Kind: DiffChanged, Key: la.key, Old: la.val, New: lb.val}
+		case la != nil:
+			d = DiffEntry{Kind: DiffRemoved, Key: la.key, Old: la.val}
+		default:
+			d = DiffEntry{Kind: DiffAdded, Key: lb.key, New: lb.val}
+		}
+		return fn(d)
+	})
+}
+
+// diffSide is one side of a lockstep walk, a subtree with its
+// remaining prefix split at offset c
+type diffSide struct {
+	n *node
+	p string
+	c int
+}
+
+// leaf returns the leaf found at the split, if the prefix ends there
+func (s diffSide) leaf() *leafNode {
+	if s.c < len(s.p) {
+		return nil
+	}
+	return s.n.leaf
+}
+
+// len returns the number of children below the split, which is
+// the rest of the prefix alone if it does not end there
+func (s diffSide) len() int {
+	if s.c < len(s.p) {
+		return 1
+	}
+	return len(s.n.edges)
+}
+
+// child returns the i-th child below the split along with its
+// remaining prefix
+func (s diffSide) child(i int) (*node, string) {
+	if s.c < len(s.p) {
+		return s.n, s.p[s.c:]
+	}
+	child := s.n.edges[i].node
+	return child, child.prefix
+}
+
+// walkLockstep walks the subtrees under a and b together in sorted
+// key order, calling fn with the leaves of every key found in either
+// of them, the leaf is nil on the side missing the key. The walk
+// stops early if fn returns true.
+func walkLockstep(a, b *node, fn func(la, lb *leafNode) bool) {
+	lockstep(a, a.prefix, b, b.prefix, fn)
+}
+
+// lockstep compares the subtrees a and b, whose remaining prefixes
+// pa and pb start at the same position of the key space, like
+// setOp.combine does. The prefixes are split where they diverge and
+// the sorted children of both sides are merged, recursing together
+// only where both sides have a child with the same label, so no key
+// is ever built. Returns true if fn stopped the walk.
+func lockstep(a *node, pa string, b *node, pb string, fn func(la, lb *leafNode) bool) bool {
+	c := longestPrefix(pa, pb)
+	sa, sb := diffSide{a, pa, c}, diffSide{b, pb, c}
+	if la, lb := sa.leaf(), sb.leaf(); (la != nil || lb != nil) && fn(la, lb) {
+		return true
+	}
+
+	i, j := 0, 0
+	for i < sa.len() || j < sb.len() {
+		var ca, cb *node
+		var qa, qb string
+		if i < sa.len() {
+			ca, qa = sa.child(i)
+		}
+		if j < sb.len() {
+			cb, qb = sb.child(j)
+		}
+		switch {
+		case cb == nil || (ca != nil && qa[0] < qb[0]):
+			if walkLeaves(ca, func(l *leafNode) bool { return fn(l, nil) }) {
+				return true
+			}
+			i++
+		case ca == nil || qb[0] < qa[0]:
+			if walkLeaves(cb, func(l *leafNode) bool { return fn(nil, l) }) {
+				return true
+			}
+			j++
+		default:
+			if lockstep(ca, qa, cb, qb, fn) {
+				return true
+			}
+			i++
+			j++
+		}
+	}
+	return false
+}
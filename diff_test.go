@@ -0,0 +1,97 @@
+package radix
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEqual(t *testing.T) {
+	a := New()
+	b := New()
+	if !Equal(a, b, nil) {
+		t.Fatalf("empty trees should be equal")
+	}
+
+	keys := []string{"", "foo", "foobar", "foobaz", "zip"}
+	for i, k := range keys {
+		a.Insert(k, []int{i})
+	}
+	// Insert in reverse order to get the same keys
+	for i := len(keys) - 1; i >= 0; i-- {
+		b.Insert(keys[i], []int{i})
+	}
+	if !Equal(a, b, nil) {
+		t.Fatalf("trees should be equal")
+	}
+	if !Equal(a, a.Clone(), nil) {
+		t.Fatalf("clone should be equal")
+	}
+
+	b.Insert("foo", []int{10})
+	if Equal(a, b, nil) {
+		t.Fatalf("trees should differ")
+	}
+	ignore := func(x, y interface{}) bool { return true }
+	if !Equal(a, b, ignore) {
+		t.Fatalf("trees should be equal ignoring values")
+	}
+
+	b.Delete("zip")
+	b.Insert("zap", []int{4})
+	if Equal(a, b, ignore) {
+		t.Fatalf("trees should differ in keys")
+	}
+}
+
+func TestDiff(t *testing.T) {
+	a := New()
+	for _, k := range []string{"", "foo", "foobar", "foobaz", "zip", "zipzap"} {
+		a.Insert(k, k)
+	}
+	b := a.Clone()
+	if d := Diff(a, b, nil); len(d) != 0 {
+		t.Fatalf("unexpected diff: %v", d)
+	}
+
+	b.Delete("")
+	b.Delete("foobar")
+	b.Insert("foob", "foob")
+	b.Insert("foobaz", "changed")
+	b.DeletePrefix("zip")
+	b.Insert("zi", "zi")
+	b.Insert("zz", "zz")
+
+	exp := []DiffEntry{
+		{DiffRemoved, "", "", nil},
+		{DiffAdded, "foob", nil, "foob"},
+		{DiffRemoved, "foobar", "foobar", nil},
+		{DiffChanged, "foobaz", "foobaz", "changed"},
+		{DiffAdded, "zi", nil, "zi"},
+		{DiffRemoved, "zip", "zip", nil},
+		{DiffRemoved, "zipzap", "zipzap", nil},
+		{DiffAdded, "zz", nil, "zz"},
+	}
+	out := Diff(a, b, nil)
+	if !reflect.DeepEqual(out, exp) {
+		t.Fatalf("mis-match:\n%v\n%v", out, exp)
+	}
+
+	// Swapping the trees should swap added and removed
+	out = Diff(b, a, nil)
+	if len(out) != len(exp) {
+		t.Fatalf("bad diff: %v", out)
+	}
+	for i, d := range out {
+		e := exp[i]
+		switch e.Kind {
+		case DiffAdded:
+			e.Kind = DiffRemoved
+		case DiffRemoved:
+			e.Kind = DiffAdded
+		}
+		e.Old, e.New = e.New, e.Old
+		if d != e {
+			t.Fatalf("mis-match: %v %v", d, e)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package radix
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// dumpWriter is used to write formatted output, remembering
+// the first error so callers only check once at the end
+type dumpWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (d *dumpWriter) printf(format string, args ...interface{}) {
+	if d.err != nil {
+		return
+	}
+	_, d.err = fmt.Fprintf(d.w, format, args...)
+}
+
+// Dump writes an indented ASCII view of the tree structure
+// to w, showing the edge label, prefix and leaf of every node
+func (t *ConcurrentTree) Dump(w io.Writer) error {
+	t.RLock()
+	defer t.RUnlock()
+	return t.Tree.Dump(w)
+}
+
+// Dump writes an indented ASCII view of the tree structure
+// to w, showing the edge label, prefix and leaf of every node.
+// This is meant for debugging, the format may change.
+//
+//	(root)
+//	`-- "f" prefix="foo" key="foo" val=1
+//	    `-- "b" prefix="ba"
+//	        +-- "r" prefix="r" key="foobar" val=2
+//	        `-- "z" prefix="z" key="foobaz" val=3
+func (t *Tree) Dump(w io.Writer) error {
+	d := &dumpWriter{w: w}
+	d.printf("(root)")
+	if t.root.leaf != nil {
+		d.printf(" key=%q val=%v", t.root.leaf.key, t.root.leaf.val)
+	}
+	d.printf("\n")
+	dumpEdges(d, t.root, "")
+	return d.err
+}
+
+// dumpEdges recursively writes the children of n
+func dumpEdges(d *dumpWriter, n *node, indent string) {
+	for i, e := range n.edges {
+		branch, next := "+-- ", "|   "
+		if i == len(n.edges)-1 {
+			branch, next = "`-- ", "    "
+		}
+		d.printf("%s%s%q prefix=%q", indent, branch, string(e.label), e.node.prefix)
+		if e.node.leaf != nil {
+			d.printf(" key=%q val=%v", e.node.leaf.key, e.node.leaf.val)
+		}
+		d.printf("\n")
+		dumpEdges(d, e.node, indent+next)
+	}
+}
+
+// WriteDOT writes the tree structure to w in the Graphviz
+// DOT language
+func (t *ConcurrentTree) WriteDOT(w io.Writer) error {
+	t.RLock()
+	defer t.RUnlock()
+	return t.Tree.WriteDOT(w)
+}
+
+// WriteDOT writes the tree structure to w in the Graphviz
+// DOT language. Every node is labelled with its prefix, nodes
+// holding a leaf are drawn with a double border and also show
+// the key, and edges are labelled with the edge byte.
+func (t *Tree) WriteDOT(w io.Writer) error {
+	d := &dumpWriter{w: w}
+	d.printf("digraph radix {\n")
+	d.printf("\tnode [shape=box];\n")
+	id := 0
+	var visit func(n *node) int
+	visit = func(n *node) int {
+		self := id
+		id++
+		label := strconv.Quote(n.prefix)
+		attrs := ""
+		if n.leaf != nil {
+			label += "\n" + "key=" + strconv.Quote(n.leaf.key)
+			attrs = ", peripheries=2"
+		}
+		d.printf("\tn%d [label=%s%s];\n", self, strconv.Quote(label), attrs)
+		for _, e := range n.edges {
+			child := visit(e.node)
+			d.printf("\tn%d -> n%d [label=%s];\n", self, child, strconv.Quote(string(e.label)))
+		}
+		return self
+	}
+	visit(t.root)
+	d.printf("}\n")
+	return d.err
+}
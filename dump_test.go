@@ -0,0 +1,58 @@
+package radix
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestDump(t *testing.T) {
+	r := New()
+	r.Insert("", 0)
+	r.Insert("foo", 1)
+	r.Insert("foobar", 2)
+	r.Insert("foobaz", 3)
+	r.Insert("zip", 4)
+
+	var buf bytes.Buffer
+	if err := r.Dump(&buf); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	exp := `(root) key="" val=0
++-- "f" prefix="foo" key="foo" val=1
+|   ` + "`" + `-- "b" prefix="ba"
+|       +-- "r" prefix="r" key="foobar" val=2
+|       ` + "`" + `-- "z" prefix="z" key="foobaz" val=3
+` + "`" + `-- "z" prefix="zip" key="zip" val=4
+`
+	if buf.String() != exp {
+		t.Fatalf("bad dump:\n%s\nexpected:\n%s", buf.String(), exp)
+	}
+}
+
+func TestWriteDOT(t *testing.T) {
+	r := NewConcurrentTree()
+	r.Insert("foo", 1)
+	r.Insert("foobar", 2)
+	r.Insert("zip", 3)
+
+	var buf bytes.Buffer
+	if err := r.WriteDOT(&buf); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	out := buf.String()
+	if !strings.HasPrefix(out, "digraph radix {\n") || !strings.HasSuffix(out, "}\n") {
+		t.Fatalf("bad graph: %s", out)
+	}
+	for _, s := range []string{
+		`n0 [label="\"\""];`,
+		`n1 [label="\"foo\"\nkey=\"foo\"", peripheries=2];`,
+		`n0 -> n1 [label="f"];`,
+		`n1 -> n2 [label="b"];`,
+		`n0 -> n3 [label="z"];`,
+	} {
+		if !strings.Contains(out, s) {
+			t.Fatalf("missing %s in:\n%s", s, out)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package radix
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// treeModel is a trivially correct map based implementation
+// that the fuzz tests compare the Tree against
+type treeModel map[string]interface{}
+
+// sortedKeys returns the keys accepted by fn in sorted order
+func (m treeModel) sortedKeys(fn func(k string) bool) []string {
+	out := []string{}
+	for k := range m {
+		if fn(k) {
+			out = append(out, k)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// FuzzTree runs random sequences of operations against a Tree
+// and a treeModel and fails on any difference between the two,
+// or if the tree is left invalid. Each operation is encoded as
+// an op byte, a key length byte and the key itself. Every
+// sequence runs on a plain and on a pooled tree. The seed corpus
+// lives in testdata/fuzz.
+func FuzzTree(f *testing.F) {
+	f.Fuzz(func(t *testing.T, ops []byte) {
+		runTreeOps(t, New(), ops)
+		runTreeOps(t, New(WithNodePool(0)), ops)
+	})
+}
+
+// runTreeOps is used to apply the operations encoded in ops to r
+// and a treeModel, failing on any difference
+func runTreeOps(t *testing.T, r *Tree, ops []byte) {
+	m := treeModel{}
+	for i := 0; len(ops) > 1; i++ {
+		op := ops[0] % 9
+		n := int(ops[1]) % 16
+		ops = ops[2:]
+		if n > len(ops) {
+			n = len(ops)
+		}
+		key := string(ops[:n])
+		ops = ops[n:]
+
+		switch op {
+		case 0:
+			old, updated := r.Insert(key, i)
+			mOld, mUpdated := m[key]
+			if updated != mUpdated || old != mOld {
+				t.Fatalf("Insert(%q) = %v, %v expected %v, %v", key, old, updated, mOld, mUpdated)
+			}
+			m[key] = i
+
+		case 1:
+			val, ok := r.Delete(key)
+			mVal, mOk := m[key]
+			if ok != mOk || val != mVal {
+				t.Fatalf("Delete(%q) = %v, %v expected %v, %v", key, val, ok, mVal, mOk)
+			}
+			delete(m, key)
+
+		case 2:
+			deleted := m.sortedKeys(func(k string) bool { return strings.HasPrefix(k, key) })
+			if num := r.DeletePrefix(key); num != len(deleted) {
+				t.Fatalf("DeletePrefix(%q) = %v expected %v", key, num, len(deleted))
+			}
+			for _, k := range deleted {
+				delete(m, k)
+			}
+
+		case 3:
+			val, ok := r.Get(key)
+			mVal, mOk := m[key]
+			if ok != mOk || val != mVal {
+				t.Fatalf("Get(%q) = %v, %v expected %v, %v", key, val, ok, mVal, mOk)
+			}
+
+		case 4:
+			k, val, ok := r.LongestPrefix(key)
+			prefixes := m.sortedKeys(func(k string) bool { return strings.HasPrefix(key, k) })
+			if len(prefixes) == 0 {
+				if ok {
+					t.Fatalf("LongestPrefix(%q) = %q expected no match", key, k)
+				}
+				break
+			}
+			mKey := prefixes[len(prefixes)-1]
+			if !ok || k != mKey || val != m[mKey] {
+				t.Fatalf("LongestPrefix(%q) = %q, %v expected %q, %v", key, k, val, mKey, m[mKey])
+			}
+
+		case 5:
+			out := []string{}
+			r.WalkPrefix(key, func(k string, v interface{}) bool {
+				if v != m[k] {
+					t.Fatalf("WalkPrefix(%q) value mis-match for %q: %v %v", key, k, v, m[k])
+				}
+				out = append(out, k)
+				return false
+			})
+			exp := m.sortedKeys(func(k string) bool { return strings.HasPrefix(k, key) })
+			if !reflect.DeepEqual(out, exp) {
+				t.Fatalf("WalkPrefix(%q) = %v expected %v", key, out, exp)
+			}
+
+		case 6:
+			out := []string{}
+			r.WalkPath(key, func(k string, v interface{}) bool {
+				out = append(out, k)
+				return false
+			})
+			exp := m.sortedKeys(func(k string) bool { return strings.HasPrefix(key, k) })
+			if !reflect.DeepEqual(out, exp) {
+				t.Fatalf("WalkPath(%q) = %v expected %v", key, out, exp)
+			}
+
+		case 7:
+			k, _, ok := r.Minimum()
+			all := m.sortedKeys(func(string) bool { return true })
+			if ok != (len(all) > 0) || (ok && k != all[0]) {
+				t.Fatalf("Minimum() = %q, %v expected %v", k, ok, all)
+			}
+
+		case 8:
+			k, _, ok := r.Maximum()
+			all := m.sortedKeys(func(string) bool { return true })
+			if ok != (len(all) > 0) || (ok && k != all[len(all)-1]) {
+				t.Fatalf("Maximum() = %q, %v expected %v", k, ok, all)
+			}
+		}
+
+		if r.Len() != len(m) {
+			t.Fatalf("bad len after op %d %q: %v %v", op, key, r.Len(), len(m))
+		}
+		if err := r.Validate(); err != nil {
+			t.Fatalf("invalid tree after op %d %q: %v", op, key, err)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package radix
+
+import "sort"
+
+// FuzzyWalkFn is used when walking the tree with WalkFuzzy. Takes
+// a key, its value and its edit distance to the query, returning
+// if iteration should be terminated.
+type FuzzyWalkFn func(s string, v interface{}, dist int) bool
+
+// FuzzyMatch is a key found by FuzzyMatches along with its
+// edit distance to the query
+type FuzzyMatch struct {
+	Key      string
+	Value    interface{}
+	Distance int
+}
+
+// fuzzyMatcher computes edit distances to a query incrementally
+type fuzzyMatcher struct {
+	query   []rune
+	maxDist int
+	damerau bool
+}
+
+// fuzzyState is the last row of the edit distance matrix after
+// matching the path to a node
+type fuzzyState struct {
+	// row holds the distances from the path to every prefix of
+	// the query, and prevRow the row before for transpositions
+	row     []int
+	prevRow []int
+
+	// prev is the last character of the path for transpositions,
+	// or -1 for an empty path
+	prev rune
+
+	// pending is carried between calls to feedRunes when a node
+	// prefix ends inside a character
+	pending string
+}
+
+// start returns the state for an empty path
+func (m *fuzzyMatcher) start() fuzzyState {
+	row := make([]int, len(m.query)+1)
+	for j := range row {
+		row[j] = j
+	}
+	return fuzzyState{row: row, prev: -1}
+}
+
+// step advances the state by the character c
+func (m *fuzzyMatcher) step(st fuzzyState, c rune) fuzzyState {
+	row := make([]int, len(m.query)+1)
+	row[0] = st.row[0] + 1
+	for j := 1; j <= len(m.query); j++ {
+		cost := 1
+		if m.query[j-1] == c {
+			cost = 0
+		}
+		d := st.row[j-1] + cost
+		if v := st.row[j] + 1; v < d {
+			d = v
+		}
+		if v := row[j-1] + 1; v < d {
+			d = v
+		}
+		if m.damerau && j > 1 && st.prevRow != nil &&
+			c == m.query[j-2] && st.prev == m.query[j-1] {
+			if v := st.prevRow[j-2] + 1; v < d {
+				d = v
+			}
+		}
+		row[j] = d
+	}
+	return fuzzyState{row: row, prevRow: st.row, prev: c}
+}
+
+// feed returns the rows after appending the bytes of text to the
+// path, no longer computed once every distance is too large
+func (m *fuzzyMatcher) feed(st fuzzyState, text string) fuzzyState {
+	pending := st.pending
+	st.pending = feedRunes(pending, text, false, func(r rune) bool {
+		if m.dead(st) {
+			return false
+		}
+		st = m.step(st, r)
+		return true
+	})
+	return st
+}
+
+// dead checks if every key below the node is too far away
+func (m *fuzzyMatcher) dead(st fuzzyState) bool {
+	for _, d := range st.row {
+		if d <= m.maxDist {
+			return false
+		}
+	}
+	return true
+}
+
+// distance returns the distance of the path itself to the query
+func (m *fuzzyMatcher) distance(st fuzzyState) int {
+	feedRunes(st.pending, "", true, func(r rune) bool {
+		st = m.step(st, r)
+		return true
+	})
+	return st.row[len(m.query)]
+}
+
+// walk is used to report the keys below n within maxDist of the
+// query, where st holds the rows for the path to n
+func (m *fuzzyMatcher) walk(n *node, st fuzzyState, fn FuzzyWalkFn) bool {
+	if m.dead(st) {
+		return false
+	}
+	if n.leaf != nil {
+		if d := m.distance(st); d <= m.maxDist && fn(n.leaf.key, n.leaf.val, d) {
+			return true
+		}
+	}
+	for _, e := range n.edges {
+		if m.walk(e.node, m.feed(st, e.node.prefix), fn) {
+			return true
+		}
+	}
+	return false
+}
+
+// WalkFuzzy is used to walk the keys within an edit distance
+// of query, see Tree.WalkFuzzy
+func (t *ConcurrentTree) WalkFuzzy(query string, maxDist int, damerau bool, fn FuzzyWalkFn) {
+	t.RLock()
+	defer t.RUnlock()
+	t.Tree.WalkFuzzy(query, maxDist, damerau, fn)
+}
+
+// WalkFuzzy is used to walk the keys within a Levenshtein distance
+// of maxDist from query, in key order. If damerau is set, swapping
+// two adjacent characters counts as a single edit. Distances are
+// counted in characters, and computed one row at a time along the
+// node prefixes, so shared prefixes are only evaluated once and a
+// subtree is abandoned as soon as no key in it can be close enough.
+func (t *Tree) WalkFuzzy(query string, maxDist int, damerau bool, fn FuzzyWalkFn) {
+	if maxDist < 0 {
+		return
+	}
+	m := &fuzzyMatcher{
+		query:   []rune(query),
+		maxDist: maxDist,
+		damerau: damerau,
+	}
+	m.walk(t.root, m.start(), fn)
+}
+
+// FuzzyMatches is used to return the keys within an edit
+// distance of query, see Tree.FuzzyMatches
+func (t *ConcurrentTree) FuzzyMatches(query string, maxDist int, damerau bool) []FuzzyMatch {
+	t.RLock()
+	defer t.RUnlock()
+	return t.Tree.FuzzyMatches(query, maxDist, damerau)
+}
+
+// FuzzyMatches is like WalkFuzzy, but returns the matches sorted
+// by distance, with ties in key order
+func (t *Tree) FuzzyMatches(query string, maxDist int, damerau bool) []FuzzyMatch {
+	var out []FuzzyMatch
+	t.WalkFuzzy(query, maxDist, damerau, func(k string, v interface{}, dist int) bool {
+		out = append(out, FuzzyMatch{Key: k, Value: v, Distance: dist})
+		return false
+	})
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i].Distance < out[j].Distance
+	})
+	return out
+}
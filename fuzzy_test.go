@@ -0,0 +1,101 @@
+package radix
+
+import (
+	"reflect"
+	"testing"
+)
+
+// editDistance is a plain implementation of the Levenshtein distance,
+// optionally counting adjacent transpositions as a single edit
+func editDistance(a, b string, damerau bool) int {
+	ra, rb := []rune(a), []rune(b)
+	d := make([][]int, len(ra)+1)
+	for i := range d {
+		d[i] = make([]int, len(rb)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			d[i][j] = d[i-1][j-1] + cost
+			if v := d[i-1][j] + 1; v < d[i][j] {
+				d[i][j] = v
+			}
+			if v := d[i][j-1] + 1; v < d[i][j] {
+				d[i][j] = v
+			}
+			if damerau && i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				if v := d[i-2][j-2] + 1; v < d[i][j] {
+					d[i][j] = v
+				}
+			}
+		}
+	}
+	return d[len(ra)][len(rb)]
+}
+
+func TestWalkFuzzy(t *testing.T) {
+	keys := []string{
+		"", "a", "ab", "apply", "appyl", "comit", "commit", "commits", "config", "configure",
+		"delete", "deploy", "describe", "get", "git", "logs", "native", "naïve", "日本", "日本語",
+	}
+	r := NewConcurrentTree()
+	for _, k := range keys {
+		r.Insert(k, nil)
+	}
+
+	queries := []string{"", "a", "aply", "apply", "comit", "confgi", "delpoy", "gte", "naive", "日語", "xyzzy"}
+	for _, q := range queries {
+		for dist := 0; dist <= 3; dist++ {
+			for _, damerau := range []bool{false, true} {
+				exp := []string{}
+				expDist := []int{}
+				for _, k := range keys {
+					if d := editDistance(q, k, damerau); d <= dist {
+						exp = append(exp, k)
+						expDist = append(expDist, d)
+					}
+				}
+				out := []string{}
+				outDist := []int{}
+				r.WalkFuzzy(q, dist, damerau, func(k string, v interface{}, d int) bool {
+					out = append(out, k)
+					outDist = append(outDist, d)
+					return false
+				})
+				if !reflect.DeepEqual(out, exp) || !reflect.DeepEqual(outDist, expDist) {
+					t.Fatalf("%q %d %v: mis-match: %v %v %v %v", q, dist, damerau, out, outDist, exp, expDist)
+				}
+			}
+		}
+	}
+}
+
+func TestFuzzyMatches(t *testing.T) {
+	r := New()
+	for _, k := range []string{"commit", "comet", "commits", "config", "compile"} {
+		r.Insert(k, nil)
+	}
+	out := r.FuzzyMatches("comit", 2, false)
+	exp := []FuzzyMatch{
+		{"comet", nil, 1},
+		{"commit", nil, 1},
+		{"commits", nil, 2},
+	}
+	if !reflect.DeepEqual(out, exp) {
+		t.Fatalf("mis-match: %v %v", out, exp)
+	}
+
+	if out := r.FuzzyMatches("ocmmit", 1, true); len(out) != 1 || out[0].Key != "commit" {
+		t.Fatalf("bad transposition match: %v", out)
+	}
+	if out := r.FuzzyMatches("ocmmit", 1, false); len(out) != 0 {
+		t.Fatalf("unexpected match: %v", out)
+	}
+}
@@ -0,0 +1,348 @@
+package radix
+
+import (
+	"path"
+	"sort"
+	"unicode/utf8"
+)
+
+// globKind is the kind of a single glob pattern token
+type globKind int
+
+const (
+	globLiteral globKind = iota
+	globAny
+	globStar
+	globClass
+)
+
+// globRange is an inclusive range of a character class
+type globRange struct {
+	lo, hi rune
+}
+
+// globToken is a single element of a parsed glob pattern
+type globToken struct {
+	kind    globKind
+	b       byte
+	negated bool
+	ranges  []globRange
+}
+
+// matches checks if a token matching a whole character accepts r
+func (g *globToken) matches(r rune) bool {
+	switch g.kind {
+	case globAny:
+		return r != '/'
+	case globClass:
+		in := false
+		for _, rg := range g.ranges {
+			if rg.lo <= r && r <= rg.hi {
+				in = true
+				break
+			}
+		}
+		return in != g.negated
+	}
+	return false
+}
+
+// parseGlob is used to parse a pattern with the syntax of path.Match
+func parseGlob(pattern string) ([]globToken, error) {
+	var out []globToken
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case '*':
+			// Consecutive stars are the same as one
+			if len(out) == 0 || out[len(out)-1].kind != globStar {
+				out = append(out, globToken{kind: globStar})
+			}
+			pattern = pattern[1:]
+
+		case '?':
+			out = append(out, globToken{kind: globAny})
+			pattern = pattern[1:]
+
+		case '[':
+			tok := globToken{kind: globClass}
+			pattern = pattern[1:]
+			if len(pattern) > 0 && pattern[0] == '^' {
+				tok.negated = true
+				pattern = pattern[1:]
+			}
+			for {
+				if len(pattern) > 0 && pattern[0] == ']' && len(tok.ranges) > 0 {
+					pattern = pattern[1:]
+					break
+				}
+				lo, rest, err := globClassChar(pattern)
+				if err != nil {
+					return nil, err
+				}
+				hi := lo
+				if len(rest) > 0 && rest[0] == '-' {
+					if hi, rest, err = globClassChar(rest[1:]); err != nil {
+						return nil, err
+					}
+					if hi < lo {
+						return nil, path.ErrBadPattern
+					}
+				}
+				tok.ranges = append(tok.ranges, globRange{lo, hi})
+				pattern = rest
+			}
+			out = append(out, tok)
+
+		case '\\':
+			if len(pattern) < 2 {
+				return nil, path.ErrBadPattern
+			}
+			pattern = pattern[1:]
+			fallthrough
+
+		default:
+			// Literals are compared byte by byte, like path.Match
+			out = append(out, globToken{kind: globLiteral, b: pattern[0]})
+			pattern = pattern[1:]
+		}
+	}
+	return out, nil
+}
+
+// globClassChar is used to parse a possibly escaped character
+// inside a character class
+func globClassChar(pattern string) (rune, string, error) {
+	if len(pattern) == 0 || pattern[0] == '-' || pattern[0] == ']' {
+		return 0, "", path.ErrBadPattern
+	}
+	if pattern[0] == '\\' {
+		pattern = pattern[1:]
+		if len(pattern) == 0 {
+			return 0, "", path.ErrBadPattern
+		}
+	}
+	r, size := utf8.DecodeRuneInString(pattern)
+	if r == utf8.RuneError && size == 1 {
+		return 0, "", path.ErrBadPattern
+	}
+	return r, pattern[size:], nil
+}
+
+// globLiteralPrefix returns the literal bytes every match of
+// the pattern starts with
+func globLiteralPrefix(tokens []globToken) string {
+	var out []byte
+	for _, tok := range tokens {
+		if tok.kind != globLiteral {
+			break
+		}
+		out = append(out, tok.b)
+	}
+	return string(out)
+}
+
+// globPos is a pattern position reachable after matching some
+// text, along with the bytes of an incomplete character read by
+// the '?' or class token found there
+type globPos struct {
+	p       int
+	partial string
+}
+
+// globState is the set of positions reachable after matching
+// some text
+type globState struct {
+	pos []globPos
+}
+
+// globClosure adds the positions reachable by letting a star
+// match nothing, keeping the positions unique
+func globClosure(tokens []globToken, pos []globPos) []globPos {
+	out := make([]globPos, 0, len(pos)+1)
+	add := func(q globPos) {
+		for _, o := range out {
+			if o == q {
+				return
+			}
+		}
+		out = append(out, q)
+	}
+	for _, q := range pos {
+		add(q)
+		for q.partial == "" && q.p < len(tokens) && tokens[q.p].kind == globStar {
+			q.p++
+			add(q)
+		}
+	}
+	return out
+}
+
+// globStep advances every position by c, a single byte of text
+func globStep(tokens []globToken, pos []globPos, c string) []globPos {
+	var next []globPos
+	for _, q := range pos {
+		if q.p == len(tokens) {
+			continue
+		}
+		switch tok := &tokens[q.p]; tok.kind {
+		case globLiteral:
+			if tok.b == c[0] {
+				next = append(next, globPos{p: q.p + 1})
+			}
+		case globStar:
+			if c[0] != '/' {
+				next = append(next, q)
+			}
+		default:
+			// A whole character is decoded first, as path.Match
+			// does with utf8.DecodeRuneInString
+			s := q.partial + c
+			r, size, ok := nextRune(s, false)
+			if !ok {
+				next = append(next, globPos{p: q.p, partial: s})
+				continue
+			}
+			next = append(next, globChar(tokens, q.p, r, s[size:])...)
+		}
+	}
+	return globClosure(tokens, next)
+}
+
+// globChar returns the positions reached by matching r with the
+// token at p, followed by the bytes of rest which were read past
+// an invalid character
+func globChar(tokens []globToken, p int, r rune, rest string) []globPos {
+	if !tokens[p].matches(r) {
+		return nil
+	}
+	pos := globClosure(tokens, []globPos{{p: p + 1}})
+	for i := 0; i < len(rest) && len(pos) > 0; i++ {
+		pos = globStep(tokens, pos, rest[i:i+1])
+	}
+	return pos
+}
+
+// feed advances the state by the bytes of text
+func (s globState) feed(tokens []globToken, text string) globState {
+	pos := s.pos
+	for i := 0; i < len(text) && len(pos) > 0; i++ {
+		pos = globStep(tokens, pos, text[i:i+1])
+	}
+	return globState{pos: pos}
+}
+
+// accepts checks if the text matched so far is a full match
+func (s globState) accepts(tokens []globToken) bool {
+	for _, q := range s.pos {
+		if globAccepts(tokens, q) {
+			return true
+		}
+	}
+	return false
+}
+
+// globAccepts checks if q is a full match at the end of the text,
+// where the bytes after the first one of an incomplete character
+// are matched again
+func globAccepts(tokens []globToken, q globPos) bool {
+	if q.partial == "" {
+		return q.p == len(tokens)
+	}
+	r, size, _ := nextRune(q.partial, true)
+	for _, next := range globChar(tokens, q.p, r, q.partial[size:]) {
+		if globAccepts(tokens, next) {
+			return true
+		}
+	}
+	return false
+}
+
+// literalBytes returns the bytes the next byte of text must be
+// one of, or false if any byte may be accepted
+func (s globState) literalBytes(tokens []globToken) ([]byte, bool) {
+	var out []byte
+	for _, q := range s.pos {
+		if q.p == len(tokens) {
+			continue
+		}
+		if tokens[q.p].kind != globLiteral {
+			return nil, false
+		}
+		out = append(out, tokens[q.p].b)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i] < out[j]
+	})
+	return out, true
+}
+
+// WalkGlob is used to walk the keys matching a glob pattern,
+// see Tree.WalkGlob
+func (t *ConcurrentTree) WalkGlob(pattern string, fn WalkFn) error {
+	t.RLock()
+	defer t.RUnlock()
+	return t.Tree.WalkGlob(pattern, fn)
+}
+
+// WalkGlob is used to walk the keys matching a glob pattern, with
+// the same syntax and semantics as path.Match: '*' matches any run
+// of bytes other than '/', '?' matches a single character other
+// than '/', "[a-z]" and "[^a-z]" match character classes and '\\'
+// escapes the next character. Literals are compared byte by byte,
+// and '?' and classes read invalid UTF-8 as utf8.RuneError one byte
+// at a time. The walk starts at the literal prefix of the pattern
+// and abandons every branch that can not match anymore, following
+// only the matching edges where the pattern expects a literal.
+// Returns path.ErrBadPattern if the pattern is malformed.
+func (t *Tree) WalkGlob(pattern string, fn WalkFn) error {
+	tokens, err := parseGlob(pattern)
+	if err != nil {
+		return err
+	}
+	n, nodePath := t.findPrefix(globLiteralPrefix(tokens))
+	if n == nil {
+		return nil
+	}
+	start := globState{pos: globClosure(tokens, []globPos{{}})}
+	walkGlob(pattern, tokens, n, start.feed(tokens, nodePath), fn)
+	return nil
+}
+
+// walkGlob recursively walks n, where st is the state after
+// matching the path to n. The states accept every key path.Match
+// does, but path.Match never backtracks into an earlier star once
+// the text after it matched, which rejects some keys when a class
+// matches '/', so accepted keys are checked with path.Match.
+func walkGlob(pattern string, tokens []globToken, n *node, st globState, fn WalkFn) bool {
+	if len(st.pos) == 0 {
+		return false
+	}
+	if n.leaf != nil && st.accepts(tokens) {
+		if ok, _ := path.Match(pattern, n.leaf.key); ok && fn(n.leaf.key, n.leaf.val) {
+			return true
+		}
+	}
+
+	visit := func(child *node) bool {
+		return walkGlob(pattern, tokens, child, st.feed(tokens, child.prefix), fn)
+	}
+	if labels, ok := st.literalBytes(tokens); ok {
+		// Only the edges starting with an expected literal can
+		// match, look them up in order
+		for i, b := range labels {
+			if i > 0 && labels[i-1] == b {
+				continue
+			}
+			if child := n.getEdge(b); child != nil && visit(child) {
+				return true
+			}
+		}
+		return false
+	}
+	for _, e := range n.edges {
+		if visit(e.node) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,64 @@
+package radix
+
+import (
+	"path"
+	"reflect"
+	"testing"
+)
+
+func TestWalkGlob(t *testing.T) {
+	keys := []string{
+		"", "ab/c", "host-01", "host-02", "host-1", "host-ab", "hostx",
+		"svc/a/config", "svc/a/config/old", "svc/b/config", "svc/b/other", "svc/config",
+		"svc/日本/config", "svc/旧/config", "x*y", "x[y]",
+		"\xa9", "\xa9\xa9b", "\xc2\xa9", "\xe2(\xa9", "\xe2\x82", "\xe2\x82/x", "\xf0\x90\x80(",
+	}
+	r := NewConcurrentTree()
+	for _, k := range keys {
+		r.Insert(k, nil)
+	}
+
+	patterns := []string{
+		"", "*", "host-??", "host-?", "host-[0-9][0-9]", "host-[^0-9]*", "host*",
+		"svc/*/config", "svc/*", "svc/?/config", "svc/??/config", "*/config", "svc/*/*",
+		"x\\[y]", "x\\*y", "x[*]y", "h*-*", "nope*", "svc/[a-b]/config", "svc[/]a/config",
+		"\xa9", "*\\\xff*", "*\xa9", "?\xa9", "??", "?", "\xe2?", "\xe2?/?", "?(*", "?(\xa9", "[^a]\xa9*",
+		"\xf0???", "\xf0?(", "?*(", "*[^a]*c",
+	}
+	for _, p := range patterns {
+		// Compare against path.Match on every key
+		exp := []string{}
+		for _, k := range keys {
+			if ok, _ := path.Match(p, k); ok {
+				exp = append(exp, k)
+			}
+		}
+		out := []string{}
+		err := r.WalkGlob(p, func(k string, v interface{}) bool {
+			out = append(out, k)
+			return false
+		})
+		if err != nil {
+			t.Fatalf("%q: %v", p, err)
+		}
+		if !reflect.DeepEqual(out, exp) {
+			t.Fatalf("%q: mis-match: %v %v", p, out, exp)
+		}
+	}
+
+	// Stop early
+	out := []string{}
+	r.WalkGlob("host-*", func(k string, v interface{}) bool {
+		out = append(out, k)
+		return len(out) == 2
+	})
+	if !reflect.DeepEqual(out, []string{"host-01", "host-02"}) {
+		t.Fatalf("bad walk: %v", out)
+	}
+
+	for _, p := range []string{"[", "[]", "[a-", "[z-a]", "x\\", "[-a]"} {
+		if err := r.WalkGlob(p, func(string, interface{}) bool { return false }); err != path.ErrBadPattern {
+			t.Fatalf("%q: expected bad pattern: %v", p, err)
+		}
+	}
+}
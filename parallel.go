@@ -0,0 +1,208 @@
+package radix
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// parallelBatchSize is how many entries ParallelWalkOrdered
+// hands to a worker at once
+const parallelBatchSize = 256
+
+// parallelTask is a subtree to be walked by a worker, or just
+// the leaf of a node whose children are walked separately
+type parallelTask struct {
+	n        *node
+	leafOnly bool
+}
+
+// ParallelWalk is used to walk the tree under a prefix on a pool
+// of workers while holding the read lock, see Tree.ParallelWalk
+func (t *ConcurrentTree) ParallelWalk(prefix string, workers int, fn WalkFn) {
+	t.RLock()
+	defer t.RUnlock()
+	t.Tree.ParallelWalk(prefix, workers, fn)
+}
+
+// ParallelWalk is used to walk the tree under a prefix, fanning out
+// the subtrees across a pool of workers. A workers count of zero or
+// less uses GOMAXPROCS workers. fn is called concurrently and in no
+// particular order, returning true stops all workers as soon as they
+// finish their current entry. The tree must not be modified until
+// ParallelWalk returns.
+func (t *Tree) ParallelWalk(prefix string, workers int, fn WalkFn) {
+	n, _ := t.findPrefix(prefix)
+	if n == nil {
+		return
+	}
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+
+	tasks := make(chan parallelTask)
+	var stopped int32
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			visit := func(k string, v interface{}) bool {
+				if atomic.LoadInt32(&stopped) != 0 {
+					return true
+				}
+				if fn(k, v) {
+					atomic.StoreInt32(&stopped, 1)
+					return true
+				}
+				return false
+			}
+			for task := range tasks {
+				if task.leafOnly {
+					visit(task.n.leaf.key, task.n.leaf.val)
+				} else {
+					recursiveWalk(task.n, visit)
+				}
+			}
+		}()
+	}
+
+	for _, task := range splitTasks(n, workers*4) {
+		if atomic.LoadInt32(&stopped) != 0 {
+			break
+		}
+		tasks <- task
+	}
+	close(tasks)
+	wg.Wait()
+}
+
+// splitTasks is used to split the subtree under n breadth first
+// into roughly target tasks that can be walked independently
+func splitTasks(n *node, target int) []parallelTask {
+	var done []parallelTask
+	queue := []*node{n}
+	for len(queue) > 0 && len(done)+len(queue) < target {
+		next := queue[0]
+		queue = queue[1:]
+		if len(next.edges) == 0 {
+			done = append(done, parallelTask{n: next})
+			continue
+		}
+		if next.leaf != nil {
+			done = append(done, parallelTask{n: next, leafOnly: true})
+		}
+		for _, e := range next.edges {
+			queue = append(queue, e.node)
+		}
+	}
+	for _, next := range queue {
+		done = append(done, parallelTask{n: next})
+	}
+	return done
+}
+
+// ParallelWalkOrdered is used to process the entries under a prefix
+// on a pool of workers while holding the read lock, see
+// Tree.ParallelWalkOrdered
+func (t *ConcurrentTree) ParallelWalkOrdered(prefix string, workers int,
+	work func(k string, v interface{}) interface{}, fn func(k string, res interface{}) bool) {
+	t.RLock()
+	defer t.RUnlock()
+	t.Tree.ParallelWalkOrdered(prefix, workers, work, fn)
+}
+
+// ParallelWalkOrdered is used to process the entries under a prefix
+// on a pool of workers while consuming the results in key order. work
+// is called concurrently for every entry, and fn is called on the
+// calling goroutine with the key and the result of work, in the same
+// order as WalkPrefix. Returning true from fn stops the walk. Entries
+// are handed to the workers in batches and only a bounded number of
+// batches is buffered ahead of fn. A workers count of zero or less
+// uses GOMAXPROCS workers. The tree must not be modified until
+// ParallelWalkOrdered returns.
+func (t *Tree) ParallelWalkOrdered(prefix string, workers int,
+	work func(k string, v interface{}) interface{}, fn func(k string, res interface{}) bool) {
+	n, _ := t.findPrefix(prefix)
+	if n == nil {
+		return
+	}
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+
+	type batch struct {
+		leaves  []*leafNode
+		results []interface{}
+		done    chan struct{}
+	}
+	jobs := make(chan *batch)
+	pending := make(chan *batch, workers*2)
+	quit := make(chan struct{})
+
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			for b := range jobs {
+				b.results = make([]interface{}, len(b.leaves))
+				for i, l := range b.leaves {
+					b.results[i] = work(l.key, l.val)
+				}
+				close(b.done)
+			}
+		}()
+	}
+
+	// Produce batches in key order, the pending channel
+	// bounds how far ahead of fn the workers can get
+	go func() {
+		defer close(pending)
+		defer close(jobs)
+		b := &batch{done: make(chan struct{})}
+		send := func() bool {
+			select {
+			case pending <- b:
+			case <-quit:
+				return false
+			}
+			select {
+			case jobs <- b:
+			case <-quit:
+				return false
+			}
+			b = &batch{done: make(chan struct{})}
+			return true
+		}
+		stopped := walkLeaves(n, func(l *leafNode) bool {
+			b.leaves = append(b.leaves, l)
+			if len(b.leaves) == parallelBatchSize {
+				return !send()
+			}
+			return false
+		})
+		if !stopped && len(b.leaves) > 0 {
+			send()
+		}
+	}()
+
+	for b := range pending {
+		<-b.done
+		stop := false
+		for i, l := range b.leaves {
+			if fn(l.key, b.results[i]) {
+				stop = true
+				break
+			}
+		}
+		if stop {
+			close(quit)
+			break
+		}
+	}
+
+	// The producer stops once quit is closed, and closing
+	// jobs then lets the workers finish
+	wg.Wait()
+}
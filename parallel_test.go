@@ -0,0 +1,96 @@
+package radix
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestParallelWalk(t *testing.T) {
+	r := NewConcurrentTree()
+	exp := []string{}
+	for i := 0; i < 5000; i++ {
+		k := fmt.Sprintf("key/%d", i)
+		r.Insert(k, i)
+		exp = append(exp, k)
+	}
+	r.Insert("key", -1)
+	r.Insert("other", -2)
+	exp = append(exp, "key")
+	sort.Strings(exp)
+
+	for _, workers := range []int{0, 1, 3, 16} {
+		var l sync.Mutex
+		out := []string{}
+		r.ParallelWalk("key", workers, func(k string, v interface{}) bool {
+			l.Lock()
+			out = append(out, k)
+			l.Unlock()
+			return false
+		})
+		sort.Strings(out)
+		if !reflect.DeepEqual(out, exp) {
+			t.Fatalf("%d workers: mis-match: %d %d", workers, len(out), len(exp))
+		}
+	}
+
+	// Stopping halts all the workers
+	var calls int32
+	r.ParallelWalk("", 4, func(k string, v interface{}) bool {
+		return atomic.AddInt32(&calls, 1) >= 10
+	})
+	if n := atomic.LoadInt32(&calls); n < 10 || n > 20 {
+		t.Fatalf("walk not stopped: %v", n)
+	}
+
+	// The workers can not fail the test themselves
+	atomic.StoreInt32(&calls, 0)
+	r.ParallelWalk("nope", 4, func(k string, v interface{}) bool {
+		atomic.AddInt32(&calls, 1)
+		return false
+	})
+	if n := atomic.LoadInt32(&calls); n != 0 {
+		t.Fatalf("should not be called: %v", n)
+	}
+}
+
+func TestParallelWalkOrdered(t *testing.T) {
+	r := NewConcurrentTree()
+	for i := 0; i < 5000; i++ {
+		r.Insert(fmt.Sprintf("key/%d", i), i)
+	}
+	r.Insert("other", -1)
+
+	exp := []string{}
+	r.WalkPrefix("key/", func(k string, v interface{}) bool {
+		exp = append(exp, fmt.Sprintf("%s=%d", k, v.(int)*2))
+		return false
+	})
+
+	double := func(k string, v interface{}) interface{} {
+		return v.(int) * 2
+	}
+	for _, workers := range []int{0, 1, 3, 16} {
+		out := []string{}
+		r.ParallelWalkOrdered("key/", workers, double, func(k string, res interface{}) bool {
+			out = append(out, fmt.Sprintf("%s=%d", k, res))
+			return false
+		})
+		if !reflect.DeepEqual(out, exp) {
+			t.Fatalf("%d workers: mis-match", workers)
+		}
+	}
+
+	// Stopping returns right away
+	out := []string{}
+	r.ParallelWalkOrdered("key/", 4, double, func(k string, res interface{}) bool {
+		out = append(out, fmt.Sprintf("%s=%d", k, res))
+		return len(out) == 300
+	})
+	if !reflect.DeepEqual(out, exp[:300]) {
+		t.Fatalf("mis-match: %v", out)
+	}
+}
@@ -0,0 +1,94 @@
+package radix
+
+import (
+	"strings"
+)
+
+// Entry is a key and value stored in the tree
+type Entry struct {
+	Key   string
+	Value interface{}
+}
+
+// PrefixIterator is used to iterate over the stored keys that
+// are a prefix of a given string, from the shortest to the
+// longest. It does not allocate, and the tree must not be
+// modified while it is in use.
+type PrefixIterator struct {
+	n      *node
+	search string
+
+	// s is the whole string, and delim the segment delimiter
+	// keys must end on if segmented is set
+	s         string
+	segmented bool
+	delim     byte
+}
+
+// Next returns the next stored key that is a prefix of the
+// string, along with its value, or false once there are no
+// more keys
+func (it *PrefixIterator) Next() (string, interface{}, bool) {
+	for it.n != nil {
+		n := it.n
+
+		// Advance to the next node on the path
+		it.n = nil
+		if len(it.search) > 0 {
+			if next := n.getEdge(it.search[0]); next != nil && strings.HasPrefix(it.search, next.prefix) {
+				it.search = it.search[len(next.prefix):]
+				it.n = next
+			}
+		}
+
+		if n.leaf != nil && (!it.segmented || segmentBoundary(it.s, len(n.leaf.key), it.delim)) {
+			return n.leaf.key, n.leaf.val, true
+		}
+	}
+	return "", nil, false
+}
+
+// PrefixIterator returns an iterator over the stored keys that are
+// a prefix of s, ordered from the shortest to the longest. This is
+// the allocation free form of AllPrefixes. If the tree was created
+// with WithSegmentDelimiter, only the keys ending on a segment
+// boundary of s are returned. ConcurrentTree users must hold the
+// read lock while iterating.
+func (t *Tree) PrefixIterator(s string) PrefixIterator {
+	return PrefixIterator{n: t.root, search: s, s: s, segmented: t.segmented, delim: t.delim}
+}
+
+// AllPrefixes is used to return every stored key that is a prefix
+// of s, ordered from the shortest to the longest
+func (t *ConcurrentTree) AllPrefixes(s string) []Entry {
+	t.RLock()
+	defer t.RUnlock()
+	return t.Tree.AllPrefixes(s)
+}
+
+// AllPrefixes is used to return every stored key that is a prefix
+// of s, ordered from the shortest to the longest. The last entry
+// is the one returned by LongestPrefix.
+func (t *Tree) AllPrefixes(s string) []Entry {
+	var out []Entry
+	it := t.PrefixIterator(s)
+	for k, v, ok := it.Next(); ok; k, v, ok = it.Next() {
+		out = append(out, Entry{Key: k, Value: v})
+	}
+	return out
+}
+
+// ShortestPrefix is like LongestPrefix, but returns the
+// shortest stored key that is a prefix of s
+func (t *ConcurrentTree) ShortestPrefix(s string) (string, interface{}, bool) {
+	t.RLock()
+	defer t.RUnlock()
+	return t.Tree.ShortestPrefix(s)
+}
+
+// ShortestPrefix is like LongestPrefix, but returns the
+// shortest stored key that is a prefix of s
+func (t *Tree) ShortestPrefix(s string) (string, interface{}, bool) {
+	it := t.PrefixIterator(s)
+	return it.Next()
+}
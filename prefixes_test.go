@@ -0,0 +1,73 @@
+package radix
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAllPrefixes(t *testing.T) {
+	r := NewConcurrentTree()
+	keys := []string{"", "foo", "foobar", "foobarbaz", "foobarbazzip", "foozip"}
+	for _, k := range keys {
+		r.Insert(k, len(k))
+	}
+
+	type exp struct {
+		inp string
+		out []string
+	}
+	cases := []exp{
+		{"", []string{""}},
+		{"a", []string{""}},
+		{"fo", []string{""}},
+		{"foo", []string{"", "foo"}},
+		{"foob", []string{"", "foo"}},
+		{"foobarba", []string{"", "foo", "foobar"}},
+		{"foobarbazzip", []string{"", "foo", "foobar", "foobarbaz", "foobarbazzip"}},
+		{"foozipzap", []string{"", "foo", "foozip"}},
+	}
+	for _, test := range cases {
+		out := []string{}
+		for _, e := range r.AllPrefixes(test.inp) {
+			if e.Value != len(e.Key) {
+				t.Fatalf("bad value: %v", e)
+			}
+			out = append(out, e.Key)
+		}
+		if !reflect.DeepEqual(out, test.out) {
+			t.Fatalf("mis-match: %v %v", out, test.out)
+		}
+
+		m, _, ok := r.ShortestPrefix(test.inp)
+		if !ok || m != "" {
+			t.Fatalf("bad shortest prefix: %v", m)
+		}
+	}
+
+	r.Delete("")
+	m, v, ok := r.ShortestPrefix("foobarbaz")
+	if !ok || m != "foo" || v != 3 {
+		t.Fatalf("bad shortest prefix: %v %v", m, v)
+	}
+	if _, _, ok := r.ShortestPrefix("zip"); ok {
+		t.Fatalf("unexpected match")
+	}
+	if out := r.AllPrefixes("zip"); len(out) != 0 {
+		t.Fatalf("unexpected match: %v", out)
+	}
+}
+
+func TestPrefixIteratorAllocs(t *testing.T) {
+	r := New()
+	for _, k := range []string{"", "foo", "foobar", "foobarbaz"} {
+		r.Insert(k, nil)
+	}
+	allocs := testing.AllocsPerRun(100, func() {
+		it := r.PrefixIterator("foobarbazzip")
+		for _, _, ok := it.Next(); ok; _, _, ok = it.Next() {
+		}
+	})
+	if allocs != 0 {
+		t.Fatalf("iterator allocates: %v", allocs)
+	}
+}
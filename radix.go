@@ -4,11 +4,13 @@ import (
 	"sort"
 	"strings"
 	"sync"
+	"unsafe"
 )
 
 // WalkFn is used when walking the tree. Takes a
 // key and value, returning if iteration should
-// be terminated.
+// be terminated. The tree must not be modified from
+// inside a WalkFn, use WalkMut for that instead.
 type WalkFn func(s string, v interface{}) bool
 
 // leafNode is used to represent a value
@@ -111,6 +113,41 @@ func (e edges) Sort() {
 type Tree struct {
 	root *node
 	size int
+
+	// pool is used to recycle nodes when WithNodePool is set
+	pool *nodePool
+
+	// segmented is set by WithSegmentDelimiter, making prefix
+	// matches stop at delim
+	segmented bool
+	delim     byte
+
+	// scores is set once InsertScored is used, from then on
+	// the score summaries of the nodes are maintained
+	scores *scoreIndex
+}
+
+// Option is used to configure a Tree when it is created
+type Option func(*Tree)
+
+// WithNodePool makes the tree recycle the nodes and leaves
+// released by Delete, DeletePrefix and node merges, instead
+// of leaving them to the garbage collector. At most max nodes
+// and max leaves are retained, a max of zero or less retains
+// everything until Compact is called. Nodes may be reused as
+// soon as they are released, so a pooled tree must not be
+// modified from inside a WalkFn.
+func WithNodePool(max int) Option {
+	return func(t *Tree) {
+		t.pool = &nodePool{max: max}
+	}
+}
+
+// nodePool is a free list of nodes and leaves
+type nodePool struct {
+	max    int
+	nodes  []*node
+	leaves []*leafNode
 }
 
 // ConcurrentTree is Thread Safe Implementation of Radix Tree
@@ -120,20 +157,20 @@ type ConcurrentTree struct {
 }
 
 // NewConcurrentTree returns an empty Concurrent Tree
-func NewConcurrentTree() *ConcurrentTree {
-	t := &ConcurrentTree{NewFromMap(nil), new(sync.RWMutex)}
+func NewConcurrentTree(opts ...Option) *ConcurrentTree {
+	t := &ConcurrentTree{NewFromMap(nil, opts...), new(sync.RWMutex)}
 	return t
 }
 
 // New returns an empty Tree
-func New() *Tree {
-	return NewFromMap(nil)
+func New(opts ...Option) *Tree {
+	return NewFromMap(nil, opts...)
 }
 
 // NewFromMap returns a new tree containing the keys
 // from an existing map
-func NewFromMap(m map[string]interface{}) *Tree {
-	t := &Tree{root: &node{}}
+func NewFromMap(m map[string]interface{}, opts ...Option) *Tree {
+	t := newTree(opts)
 	for k, v := range m {
 		t.Insert(k, v)
 	}
@@ -142,8 +179,8 @@ func NewFromMap(m map[string]interface{}) *Tree {
 
 // NewConcurrentTreeFromMap returns a new ConcurrentTree containing the keys
 // from an existing map
-func NewConcurrentTreeFromMap(m map[string]interface{}) *ConcurrentTree {
-	t := &Tree{root: &node{}}
+func NewConcurrentTreeFromMap(m map[string]interface{}, opts ...Option) *ConcurrentTree {
+	t := newTree(opts)
 	ct := &ConcurrentTree{t, new(sync.RWMutex)}
 	ct.RLock()
 	defer ct.RUnlock()
@@ -153,6 +190,110 @@ func NewConcurrentTreeFromMap(m map[string]interface{}) *ConcurrentTree {
 	return ct
 }
 
+// newTree returns an empty tree with the options applied
+func newTree(opts []Option) *Tree {
+	t := &Tree{root: &node{}}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t
+}
+
+// emptyLike returns an empty tree with the same options as t,
+// and a node pool of its own if t has one
+func (t *Tree) emptyLike() *Tree {
+	out := &Tree{root: &node{}, segmented: t.segmented, delim: t.delim}
+	if t.pool != nil {
+		out.pool = &nodePool{max: t.pool.max}
+	}
+	return out
+}
+
+// newNode returns an empty node, reusing a pooled one if possible
+func (t *Tree) newNode() *node {
+	if p := t.pool; p != nil {
+		if num := len(p.nodes); num > 0 {
+			n := p.nodes[num-1]
+			p.nodes[num-1] = nil
+			p.nodes = p.nodes[:num-1]
+			return n
+		}
+	}
+	return &node{}
+}
+
+// newLeaf returns a leaf for the key and value, reusing
+// a pooled one if possible
+func (t *Tree) newLeaf(k string, v interface{}) *leafNode {
+	if p := t.pool; p != nil {
+		if num := len(p.leaves); num > 0 {
+			l := p.leaves[num-1]
+			p.leaves[num-1] = nil
+			p.leaves = p.leaves[:num-1]
+			l.key = k
+			l.val = v
+			return l
+		}
+	}
+	return &leafNode{key: k, val: v}
+}
+
+// freeNode hands a node that is no longer part of the tree
+// back to the pool. The edges backing array is kept for reuse.
+func (t *Tree) freeNode(n *node) {
+	t.scores.forget(n, nil)
+	p := t.pool
+	if p == nil || (p.max > 0 && len(p.nodes) >= p.max) {
+		return
+	}
+	for i := range n.edges {
+		n.edges[i] = edge{}
+	}
+	*n = node{edges: n.edges[:0]}
+	p.nodes = append(p.nodes, n)
+}
+
+// freeLeaf hands a leaf that is no longer part of the tree
+// back to the pool
+func (t *Tree) freeLeaf(l *leafNode) {
+	t.scores.forget(nil, l)
+	p := t.pool
+	if p == nil || (p.max > 0 && len(p.leaves) >= p.max) {
+		return
+	}
+	*l = leafNode{}
+	p.leaves = append(p.leaves, l)
+}
+
+// freeSubtree hands every node and leaf below n back to the pool
+func (t *Tree) freeSubtree(n *node) {
+	for _, e := range n.edges {
+		t.freeSubtree(e.node)
+		if e.node.leaf != nil {
+			t.freeLeaf(e.node.leaf)
+		}
+		e.node.leaf = nil
+		t.freeNode(e.node)
+	}
+}
+
+// Compact is used to release the nodes retained by the node
+// pool back to the garbage collector
+func (t *ConcurrentTree) Compact() {
+	t.Lock()
+	defer t.Unlock()
+	t.Tree.Compact()
+}
+
+// Compact is used to release the nodes retained by the node
+// pool back to the garbage collector
+func (t *Tree) Compact() {
+	if t.pool != nil {
+		t.pool.nodes = nil
+		t.pool.leaves = nil
+	}
+}
+
 // Len is used to return the number of elements in the tree
 func (t *Tree) Len() int {
 	return t.size
@@ -165,6 +306,88 @@ func (t *ConcurrentTree) Len() int {
 	return t.Tree.Len()
 }
 
+// Stats describes the shape and approximate memory
+// footprint of a tree
+type Stats struct {
+	// Nodes is the number of nodes, including the root
+	Nodes int
+
+	// Leaves is the number of nodes holding a value
+	Leaves int
+
+	// Edges is the number of parent to child edges
+	Edges int
+
+	// MaxDepth is the depth of the deepest leaf, with the
+	// root at depth zero
+	MaxDepth int
+
+	// AvgDepth is the average depth of all leaves
+	AvgDepth float64
+
+	// FanOut is a histogram of child counts, FanOut[i]
+	// is the number of nodes having exactly i children
+	FanOut []int
+
+	// PrefixBytes is the total length of all node prefixes
+	PrefixBytes int
+
+	// KeyBytes is the total length of all stored keys
+	KeyBytes int
+
+	// HeapBytes is an estimate of the memory held by the
+	// tree structure, excluding the stored values. Node
+	// prefixes usually share memory with the keys so they
+	// are not counted separately.
+	HeapBytes int
+}
+
+// Stats is used to collect structural statistics about the tree
+func (t *ConcurrentTree) Stats() Stats {
+	t.RLock()
+	defer t.RUnlock()
+	return t.Tree.Stats()
+}
+
+// Stats is used to collect structural statistics about the tree
+func (t *Tree) Stats() Stats {
+	var st Stats
+	var depthSum, edgeCap int
+	var visit func(n *node, depth int)
+	visit = func(n *node, depth int) {
+		st.Nodes++
+		st.Edges += len(n.edges)
+		st.PrefixBytes += len(n.prefix)
+		edgeCap += cap(n.edges)
+		for len(st.FanOut) <= len(n.edges) {
+			st.FanOut = append(st.FanOut, 0)
+		}
+		st.FanOut[len(n.edges)]++
+		if n.leaf != nil {
+			st.Leaves++
+			st.KeyBytes += len(n.leaf.key)
+			depthSum += depth
+			if depth > st.MaxDepth {
+				st.MaxDepth = depth
+			}
+		}
+		for _, e := range n.edges {
+			visit(e.node, depth+1)
+		}
+	}
+	visit(t.root, 0)
+
+	if st.Leaves > 0 {
+		st.AvgDepth = float64(depthSum) / float64(st.Leaves)
+	}
+	st.HeapBytes = int(unsafe.Sizeof(Tree{})) +
+		st.Nodes*int(unsafe.Sizeof(node{})) +
+		st.Leaves*int(unsafe.Sizeof(leafNode{})) +
+		edgeCap*int(unsafe.Sizeof(edge{})) +
+		st.KeyBytes
+	return st
+}
+
 // longestPrefix finds the length of the shared prefix
 // of two strings
 func longestPrefix(k1, k2 string) int {
@@ -192,6 +415,17 @@ func (t *ConcurrentTree) Insert(s string, v interface{}) (interface{}, bool) {
 // Insert is used to add a newentry or update
 // an existing entry. Returns if updated.
 func (t *Tree) Insert(s string, v interface{}) (interface{}, bool) {
+	_, old, updated := t.insert(s, v)
+	if !updated {
+		t.rescore(s)
+	}
+	return old, updated
+}
+
+// insert does the actual insertion, also returning the leaf
+// holding the key. New leaves get a score of zero, updated
+// ones keep their score.
+func (t *Tree) insert(s string, v interface{}) (*leafNode, interface{}, bool) {
 	var parent *node
 	n := t.root
 	search := s
@@ -201,15 +435,12 @@ func (t *Tree) Insert(s string, v interface{}) (interface{}, bool) {
 			if n.isLeaf() {
 				old := n.leaf.val
 				n.leaf.val = v
-				return old, true
+				return n.leaf, old, true
 			}
 
-			n.leaf = &leafNode{
-				key: s,
-				val: v,
-			}
+			n.leaf = t.newLeaf(s, v)
 			t.size++
-			return nil, false
+			return n.leaf, nil, false
 		}
 
 		// Look for the edge
@@ -218,19 +449,16 @@ func (t *Tree) Insert(s string, v interface{}) (interface{}, bool) {
 
 		// No edge, create one
 		if n == nil {
+			child := t.newNode()
+			child.leaf = t.newLeaf(s, v)
+			child.prefix = search
 			e := edge{
 				label: search[0],
-				node: &node{
-					leaf: &leafNode{
-						key: s,
-						val: v,
-					},
-					prefix: search,
-				},
+				node:  child,
 			}
 			parent.addEdge(e)
 			t.size++
-			return nil, false
+			return child.leaf, nil, false
 		}
 
 		// Determine longest prefix of the search key on match
@@ -242,9 +470,8 @@ func (t *Tree) Insert(s string, v interface{}) (interface{}, bool) {
 
 		// Split the node
 		t.size++
-		child := &node{
-			prefix: search[:commonPrefix],
-		}
+		child := t.newNode()
+		child.prefix = search[:commonPrefix]
 		parent.updateEdge(search[0], child)
 
 		// Restore the existing node
@@ -255,27 +482,24 @@ func (t *Tree) Insert(s string, v interface{}) (interface{}, bool) {
 		n.prefix = n.prefix[commonPrefix:]
 
 		// Create a new leaf node
-		leaf := &leafNode{
-			key: s,
-			val: v,
-		}
+		leaf := t.newLeaf(s, v)
 
 		// If the new key is a subset, add to this node
 		search = search[commonPrefix:]
 		if len(search) == 0 {
 			child.leaf = leaf
-			return nil, false
+			return leaf, nil, false
 		}
 
 		// Create a new edge for the node
+		nn := t.newNode()
+		nn.leaf = leaf
+		nn.prefix = search
 		child.addEdge(edge{
 			label: search[0],
-			node: &node{
-				leaf:   leaf,
-				prefix: search,
-			},
+			node:  nn,
 		})
-		return nil, false
+		return leaf, nil, false
 	}
 }
 
@@ -323,25 +547,29 @@ func (t *Tree) Delete(s string) (interface{}, bool) {
 DELETE:
 	// Delete the leaf
 	leaf := n.leaf
+	val := leaf.val
 	n.leaf = nil
 	t.size--
+	t.freeLeaf(leaf)
 
 	// Check if we should delete this node from the parent
 	if parent != nil && len(n.edges) == 0 {
 		parent.delEdge(label)
+		t.freeNode(n)
 	}
 
 	// Check if we should merge this node
 	if n != t.root && len(n.edges) == 1 {
-		n.mergeChild()
+		t.freeNode(n.mergeChild())
 	}
 
 	// Check if we should merge the parent's other child
 	if parent != nil && parent != t.root && len(parent.edges) == 1 && !parent.isLeaf() {
-		parent.mergeChild()
+		t.freeNode(parent.mergeChild())
 	}
 
-	return leaf.val, true
+	t.rescore(s)
+	return val, true
 }
 
 // DeletePrefix is used to delete the subtree under a prefix
@@ -357,7 +585,11 @@ func (t *ConcurrentTree) DeletePrefix(s string) int {
 // Returns how many nodes were deleted
 // Use this to delete large subtrees efficiently
 func (t *Tree) DeletePrefix(s string) int {
-	return t.deletePrefix(nil, t.root, s)
+	deleted := t.deletePrefix(nil, t.root, s)
+	if deleted > 0 {
+		t.rescore(s)
+	}
+	return deleted
 }
 
 // delete does a recursive deletion
@@ -371,14 +603,25 @@ func (t *Tree) deletePrefix(parent, n *node, prefix string) int {
 			subTreeSize++
 			return false
 		})
+		t.scores.forgetAll(n)
 		if n.isLeaf() {
+			t.freeLeaf(n.leaf)
 			n.leaf = nil
 		}
+		if t.pool != nil {
+			t.freeSubtree(n)
+		}
 		n.edges = nil // deletes the entire subtree
 
+		// Remove the now empty node from the parent
+		if parent != nil {
+			parent.delEdge(n.prefix[0])
+			t.freeNode(n)
+		}
+
 		// Check if we should merge the parent's other child
 		if parent != nil && parent != t.root && len(parent.edges) == 1 && !parent.isLeaf() {
-			parent.mergeChild()
+			t.freeNode(parent.mergeChild())
 		}
 		t.size -= subTreeSize
 		return subTreeSize
@@ -400,12 +643,181 @@ func (t *Tree) deletePrefix(parent, n *node, prefix string) int {
 	return t.deletePrefix(n, child, prefix)
 }
 
-func (n *node) mergeChild() {
+// DeletePrefixFunc is used to delete the subtree under a prefix,
+// calling fn for every removed entry
+func (t *ConcurrentTree) DeletePrefixFunc(s string, fn WalkFn) int {
+	t.Lock()
+	defer t.Unlock()
+	return t.Tree.DeletePrefixFunc(s, fn)
+}
+
+// DeletePrefixFunc is used to delete the subtree under a prefix,
+// calling fn for every removed entry in order so that values can
+// be released. The subtree is detached first, so fn can not see
+// the removed entries in the tree. Returning true from fn stops
+// the calls, but the entries are still deleted. Returns how many
+// entries were deleted.
+func (t *Tree) DeletePrefixFunc(s string, fn WalkFn) int {
+	n, _ := t.detachPrefix(s)
+	if n == nil {
+		return 0
+	}
+	deleted := 0
+	stopped := false
+	recursiveWalk(n, func(k string, v interface{}) bool {
+		deleted++
+		if !stopped {
+			stopped = fn(k, v)
+		}
+		return false
+	})
+	t.size -= deleted
+	t.scores.forgetAll(n)
+	if t.pool != nil {
+		t.freeSubtree(n)
+		if n.leaf != nil {
+			t.freeLeaf(n.leaf)
+		}
+		n.leaf = nil
+		t.freeNode(n)
+	}
+	return deleted
+}
+
+// DeleteFunc is used to delete the entries under a prefix
+// for which pred returns true
+func (t *ConcurrentTree) DeleteFunc(prefix string, pred func(k string, v interface{}) bool) int {
+	t.Lock()
+	defer t.Unlock()
+	return t.Tree.DeleteFunc(prefix, pred)
+}
+
+// DeleteFunc is used to delete the entries under a prefix for
+// which pred returns true. The subtree is pruned in a single
+// traversal, merging nodes as needed. pred is called in key
+// order and must not modify the tree. Returns how many entries
+// were deleted.
+func (t *Tree) DeleteFunc(prefix string, pred func(k string, v interface{}) bool) int {
+	parent, n, _ := t.seekPrefix(prefix)
+	if n == nil {
+		return 0
+	}
+	deleted := t.deleteFunc(n, pred)
+	t.size -= deleted
+	if parent == nil {
+		return deleted
+	}
+
+	// Check if we should delete or merge the topmost node
+	if !n.isLeaf() && len(n.edges) == 0 {
+		parent.delEdge(n.prefix[0])
+		t.freeNode(n)
+		if parent != t.root && len(parent.edges) == 1 && !parent.isLeaf() {
+			t.freeNode(parent.mergeChild())
+		}
+	} else if !n.isLeaf() && len(n.edges) == 1 {
+		t.freeNode(n.mergeChild())
+	}
+	t.rescore(prefix)
+	return deleted
+}
+
+// Retain is used to delete the entries under a prefix for
+// which pred returns false
+func (t *ConcurrentTree) Retain(prefix string, pred func(k string, v interface{}) bool) int {
+	t.Lock()
+	defer t.Unlock()
+	return t.Tree.Retain(prefix, pred)
+}
+
+// Retain is used to delete the entries under a prefix for
+// which pred returns false, it is the inverse of DeleteFunc.
+// Returns how many entries were deleted.
+func (t *Tree) Retain(prefix string, pred func(k string, v interface{}) bool) int {
+	return t.DeleteFunc(prefix, func(k string, v interface{}) bool {
+		return !pred(k, v)
+	})
+}
+
+// deleteFunc recursively deletes the leaves under n accepted
+// by pred. The children of n are deleted or merged as needed,
+// fixing up n itself is left to the caller.
+func (t *Tree) deleteFunc(n *node, pred func(k string, v interface{}) bool) int {
+	deleted := 0
+	if n.isLeaf() && pred(n.leaf.key, n.leaf.val) {
+		t.freeLeaf(n.leaf)
+		n.leaf = nil
+		deleted++
+	}
+
+	for i := 0; i < len(n.edges); {
+		child := n.edges[i].node
+		deleted += t.deleteFunc(child, pred)
+		if !child.isLeaf() {
+			switch len(child.edges) {
+			case 0:
+				n.delEdge(n.edges[i].label)
+				t.freeNode(child)
+				continue
+			case 1:
+				t.freeNode(child.mergeChild())
+			}
+		}
+		i++
+	}
+	if t.scores != nil {
+		t.scores.update(n)
+	}
+	return deleted
+}
+
+// PopPrefix is used to remove the subtree under a prefix,
+// returning the removed entries as a new tree
+func (t *ConcurrentTree) PopPrefix(s string) *Tree {
+	t.Lock()
+	defer t.Unlock()
+	return t.Tree.PopPrefix(s)
+}
+
+// PopPrefix is used to remove the subtree under a prefix,
+// returning the removed entries as a new tree with the same
+// options. The nodes are moved to the new tree instead of being
+// copied.
+func (t *Tree) PopPrefix(s string) *Tree {
+	out := t.emptyLike()
+	n, path := t.detachPrefix(s)
+	if n == nil {
+		return out
+	}
+	recursiveWalk(n, func(string, interface{}) bool {
+		out.size++
+		return false
+	})
+	t.size -= out.size
+	if t.scores != nil {
+		out.useScores().moveFrom(t.scores, n)
+	}
+	if path == "" {
+		out.root = n
+	} else {
+		n.prefix = path
+		out.root.edges = edges{{label: path[0], node: n}}
+	}
+	out.rescore("")
+	return out
+}
+
+// mergeChild folds the only child of n into n, returning
+// the child node which is no longer part of the tree
+func (n *node) mergeChild() *node {
 	e := n.edges[0]
 	child := e.node
 	n.prefix = n.prefix + child.prefix
 	n.leaf = child.leaf
 	n.edges = child.edges
+	child.leaf = nil
+	child.edges = nil
+	return child
 }
 
 // Get is used to lookup a specific key, returning
@@ -458,7 +870,22 @@ func (t *ConcurrentTree) LongestPrefix(s string) (string, interface{}, bool) {
 
 // LongestPrefix is like Get, but instead of an
 // exact match, it will return the longest prefix match.
+// If the tree was created with WithSegmentDelimiter this
+// is the same as LongestPrefixSegment.
 func (t *Tree) LongestPrefix(s string) (string, interface{}, bool) {
+	if t.segmented {
+		return t.LongestPrefixSegment(s, t.delim)
+	}
+	if last, _ := t.longestMatch(s); last != nil {
+		return last.key, last.val, true
+	}
+	return "", nil, false
+}
+
+// longestMatch returns the leaf of the longest key that is a
+// prefix of s, or nil. It also reports if s ran out while a
+// longer key could still have matched.
+func (t *Tree) longestMatch(s string) (*leafNode, bool) {
 	var last *leafNode
 	n := t.root
 	search := s
@@ -470,26 +897,22 @@ func (t *Tree) LongestPrefix(s string) (string, interface{}, bool) {
 
 		// Check for key exhaution
 		if len(search) == 0 {
-			break
+			return last, len(n.edges) > 0
 		}
 
 		// Look for an edge
 		n = n.getEdge(search[0])
 		if n == nil {
-			break
+			return last, false
 		}
 
 		// Consume the search prefix
 		if strings.HasPrefix(search, n.prefix) {
 			search = search[len(n.prefix):]
 		} else {
-			break
+			return last, strings.HasPrefix(n.prefix, search)
 		}
 	}
-	if last != nil {
-		return last.key, last.val, true
-	}
-	return "", nil, false
 }
 
 // Minimum is used to return the minimum value in the tree
@@ -546,6 +969,13 @@ func (t *Tree) Walk(fn WalkFn) {
 // WalkPrefixGet is used to walk the tree under a prefix and
 // to get values.
 func (t *Tree) WalkPrefixGet(prefix string, li *[]interface{}) {
+	if t.segmented {
+		t.WalkPrefixSegment(prefix, t.delim, func(k string, v interface{}) bool {
+			*li = append(*li, v)
+			return false
+		})
+		return
+	}
 	n := t.root
 	search := prefix
 	for {
@@ -596,8 +1026,20 @@ func (t *ConcurrentTree) WalkPrefix(prefix string, fn WalkFn) {
 	t.Tree.WalkPrefix(prefix, fn)
 }
 
-// WalkPrefix is used to walk the tree under a prefix
+// WalkPrefix is used to walk the tree under a prefix. If the
+// tree was created with WithSegmentDelimiter this is the same
+// as WalkPrefixSegment.
 func (t *Tree) WalkPrefix(prefix string, fn WalkFn) {
+	if t.segmented {
+		t.WalkPrefixSegment(prefix, t.delim, fn)
+		return
+	}
+	t.walkPrefix(prefix, fn)
+}
+
+// walkPrefix is used to walk the tree under a prefix, ignoring
+// any segment delimiter
+func (t *Tree) walkPrefix(prefix string, fn WalkFn) {
 	n := t.root
 	search := prefix
 	for {
@@ -641,13 +1083,16 @@ func (t *ConcurrentTree) WalkPath(path string, fn WalkFn) {
 // WalkPath is used to walk the tree, but only visiting nodes
 // from the root down to a given leaf. Where WalkPrefix walks
 // all the entries *under* the given prefix, this walks the
-// entries *above* the given prefix.
+// entries *above* the given prefix. If the tree was created
+// with WithSegmentDelimiter, only the keys ending on a segment
+// boundary of path are visited.
 func (t *Tree) WalkPath(path string, fn WalkFn) {
 	n := t.root
 	search := path
 	for {
 		// Visit the leaf values if any
-		if n.leaf != nil && fn(n.leaf.key, n.leaf.val) {
+		if n.leaf != nil && (!t.segmented || segmentBoundary(path, len(n.leaf.key), t.delim)) &&
+			fn(n.leaf.key, n.leaf.val) {
 			return
 		}
 
@@ -708,3 +1153,68 @@ func (t *Tree) ToMap() map[string]interface{} {
 	})
 	return out
 }
+
+// Clone is used to return a copy of the tree that can be
+// modified independently. Values are shared with the original.
+func (t *ConcurrentTree) Clone() *ConcurrentTree {
+	t.RLock()
+	defer t.RUnlock()
+	return &ConcurrentTree{t.Tree.Clone(), new(sync.RWMutex)}
+}
+
+// CloneWith is like Clone, but every value is passed through
+// fn so that values can be deep copied
+func (t *ConcurrentTree) CloneWith(fn func(v interface{}) interface{}) *ConcurrentTree {
+	t.RLock()
+	defer t.RUnlock()
+	return &ConcurrentTree{t.Tree.CloneWith(fn), new(sync.RWMutex)}
+}
+
+// Clone is used to return a copy of the tree that can be
+// modified independently. The node structure is copied
+// directly instead of re-inserting every key. Values are
+// shared with the original.
+func (t *Tree) Clone() *Tree {
+	return t.CloneWith(nil)
+}
+
+// CloneWith is like Clone, but every value is passed through
+// fn so that values can be deep copied. A nil fn copies the
+// values as is.
+func (t *Tree) CloneWith(fn func(v interface{}) interface{}) *Tree {
+	out := t.emptyLike()
+	out.size = t.size
+	out.root, _ = cloneNode(t.root, fn, nil)
+	if t.scores != nil {
+		out.useScores().copyFrom(t.scores, out.root, t.root)
+	}
+	return out
+}
+
+// cloneNode recursively copies n and everything below it, passing
+// values through fn and keys through key when they are not nil.
+// Returns the copy and the number of leaves in it.
+func cloneNode(n *node, fn func(v interface{}) interface{}, key func(k string) string) (*node, int) {
+	nc := &node{prefix: n.prefix}
+	leaves := 0
+	if n.leaf != nil {
+		k, val := n.leaf.key, n.leaf.val
+		if fn != nil {
+			val = fn(val)
+		}
+		if key != nil {
+			k = key(k)
+		}
+		nc.leaf = &leafNode{key: k, val: val}
+		leaves++
+	}
+	if len(n.edges) > 0 {
+		nc.edges = make(edges, len(n.edges))
+		for i, e := range n.edges {
+			child, num := cloneNode(e.node, fn, key)
+			nc.edges[i] = edge{label: e.label, node: child}
+			leaves += num
+		}
+	}
+	return nc, leaves
+}
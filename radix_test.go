@@ -198,6 +198,352 @@ func TestDeletePrefix(t *testing.T) {
 	}
 }
 
+func TestDeletePrefixFunc(t *testing.T) {
+	type exp struct {
+		inp     []string
+		prefix  string
+		out     []string
+		removed []string
+	}
+
+	cases := []exp{
+		{[]string{"", "A", "AB", "ABC", "R", "S"}, "A", []string{"", "R", "S"}, []string{"A", "AB", "ABC"}},
+		{[]string{"", "A", "AB", "ABC", "R", "S"}, "ABC", []string{"", "A", "AB", "R", "S"}, []string{"ABC"}},
+		{[]string{"", "A", "AB", "ABC", "R", "S"}, "", []string{}, []string{"", "A", "AB", "ABC", "R", "S"}},
+		{[]string{"", "A", "AB", "ABC", "R", "S"}, "SS", []string{"", "A", "AB", "ABC", "R", "S"}, []string{}},
+		{[]string{"foo/bar", "foo/baz", "zip"}, "foo/b", []string{"zip"}, []string{"foo/bar", "foo/baz"}},
+	}
+
+	for _, pool := range []bool{false, true} {
+		for _, test := range cases {
+			var opts []Option
+			if pool {
+				opts = append(opts, WithNodePool(0))
+			}
+			r := New(opts...)
+			for _, ss := range test.inp {
+				r.Insert(ss, ss)
+			}
+
+			removed := []string{}
+			deleted := r.DeletePrefixFunc(test.prefix, func(s string, v interface{}) bool {
+				if v != s {
+					t.Fatalf("bad value: %v %v", s, v)
+				}
+				removed = append(removed, s)
+				return false
+			})
+			if deleted != len(test.removed) {
+				t.Fatalf("Bad delete, expected %v to be deleted but got %v", len(test.removed), deleted)
+			}
+			if !reflect.DeepEqual(removed, test.removed) {
+				t.Fatalf("mis-match: %v %v", removed, test.removed)
+			}
+			if err := r.Validate(); err != nil {
+				t.Fatalf("err: %v", err)
+			}
+
+			out := []string{}
+			r.Walk(func(s string, v interface{}) bool {
+				out = append(out, s)
+				return false
+			})
+			if !reflect.DeepEqual(out, test.out) {
+				t.Fatalf("mis-match: %v %v", out, test.out)
+			}
+		}
+	}
+
+	// Stopping the callbacks still deletes everything
+	r := New()
+	for _, k := range []string{"A", "AB", "ABC"} {
+		r.Insert(k, nil)
+	}
+	calls := 0
+	deleted := r.DeletePrefixFunc("A", func(s string, v interface{}) bool {
+		calls++
+		return true
+	})
+	if calls != 1 || deleted != 3 || r.Len() != 0 {
+		t.Fatalf("bad delete: %v %v %v", calls, deleted, r.Len())
+	}
+}
+
+func TestDeleteFunc(t *testing.T) {
+	keys := []string{"", "A", "AB", "ABC", "ABD", "AC", "R", "S"}
+	odd := func(k string, v interface{}) bool {
+		return v.(int)%2 == 1
+	}
+
+	type exp struct {
+		prefix  string
+		deleted int
+		out     []string
+	}
+	cases := []exp{
+		{"", 4, []string{"", "AB", "ABD", "R"}},
+		{"A", 3, []string{"", "AB", "ABD", "R", "S"}},
+		{"AB", 1, []string{"", "A", "AB", "ABD", "AC", "R", "S"}},
+		{"ABC", 1, []string{"", "A", "AB", "ABD", "AC", "R", "S"}},
+		{"ABD", 0, keys},
+		{"X", 0, keys},
+	}
+	for _, pool := range []bool{false, true} {
+		for _, test := range cases {
+			var opts []Option
+			if pool {
+				opts = append(opts, WithNodePool(0))
+			}
+			r := New(opts...)
+			for i, k := range keys {
+				r.Insert(k, i)
+			}
+			if deleted := r.DeleteFunc(test.prefix, odd); deleted != test.deleted {
+				t.Fatalf("%q: bad delete: %v %v", test.prefix, deleted, test.deleted)
+			}
+			if err := r.Validate(); err != nil {
+				t.Fatalf("%q: %v", test.prefix, err)
+			}
+			out := []string{}
+			r.Walk(func(s string, v interface{}) bool {
+				out = append(out, s)
+				return false
+			})
+			if !reflect.DeepEqual(out, test.out) {
+				t.Fatalf("%q: mis-match: %v %v", test.prefix, out, test.out)
+			}
+		}
+	}
+
+	// Deleting everything below a node without a leaf
+	r := New()
+	for _, k := range []string{"foo/bar", "foo/baz", "foo/zip", "zap"} {
+		r.Insert(k, nil)
+	}
+	r.DeleteFunc("foo/", func(k string, v interface{}) bool { return k != "foo/zip" })
+	if err := r.Validate(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	r.DeleteFunc("foo/", func(k string, v interface{}) bool { return true })
+	if err := r.Validate(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if r.Len() != 1 {
+		t.Fatalf("bad len: %v", r.Len())
+	}
+}
+
+func TestRetain(t *testing.T) {
+	r := NewConcurrentTree()
+	for i, k := range []string{"", "A", "AB", "ABC", "ABD", "AC", "R", "S"} {
+		r.Insert(k, i)
+	}
+	deleted := r.Retain("A", func(k string, v interface{}) bool {
+		return len(k) == 3
+	})
+	if deleted != 3 {
+		t.Fatalf("bad delete: %v", deleted)
+	}
+	if err := r.Validate(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	exp := map[string]interface{}{"": 0, "ABC": 3, "ABD": 4, "R": 6, "S": 7}
+	if out := r.ToMap(); !reflect.DeepEqual(out, exp) {
+		t.Fatalf("mis-match: %v %v", out, exp)
+	}
+}
+
+func TestPopPrefix(t *testing.T) {
+	r := NewConcurrentTree()
+	for _, k := range []string{"", "foo/bar", "foo/baz", "foobar", "zip"} {
+		r.Insert(k, k)
+	}
+
+	popped := r.PopPrefix("foo/")
+	if err := popped.Validate(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	exp := map[string]interface{}{"foo/bar": "foo/bar", "foo/baz": "foo/baz"}
+	if out := popped.ToMap(); !reflect.DeepEqual(out, exp) {
+		t.Fatalf("mis-match: %v %v", out, exp)
+	}
+	if err := r.Validate(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if r.Len() != 3 {
+		t.Fatalf("bad len: %v", r.Len())
+	}
+
+	if popped := r.PopPrefix("nope"); popped.Len() != 0 {
+		t.Fatalf("bad pop: %v", popped.ToMap())
+	}
+
+	popped = r.PopPrefix("")
+	if popped.Len() != 3 || r.Len() != 0 {
+		t.Fatalf("bad len: %v %v", popped.Len(), r.Len())
+	}
+	if _, ok := popped.Get(""); !ok {
+		t.Fatalf("missing root key")
+	}
+	if err := popped.Validate(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+}
+
+func TestNodePool(t *testing.T) {
+	r := New(WithNodePool(0))
+
+	inp := make(map[string]interface{})
+	for i := 0; i < 1000; i++ {
+		inp[generateUUID()] = i
+	}
+	for round := 0; round < 3; round++ {
+		for k, v := range inp {
+			r.Insert(k, v)
+		}
+		if r.Len() != len(inp) {
+			t.Fatalf("bad length: %v %v", r.Len(), len(inp))
+		}
+		for k, v := range inp {
+			out, ok := r.Get(k)
+			if !ok || out != v {
+				t.Fatalf("value mis-match: %v %v", out, v)
+			}
+		}
+		for k, v := range inp {
+			out, ok := r.Delete(k)
+			if !ok || out != v {
+				t.Fatalf("value mis-match: %v %v", out, v)
+			}
+		}
+		if r.Len() != 0 {
+			t.Fatalf("bad length: %v", r.Len())
+		}
+		if len(r.pool.nodes) == 0 || len(r.pool.leaves) == 0 {
+			t.Fatalf("nothing was pooled")
+		}
+	}
+
+	for k, v := range inp {
+		r.Insert(k, v)
+	}
+	if n := r.DeletePrefix(""); n != len(inp) {
+		t.Fatalf("bad delete: %v %v", n, len(inp))
+	}
+	if len(r.pool.leaves) < len(inp) {
+		t.Fatalf("leaves not pooled: %v", len(r.pool.leaves))
+	}
+
+	r.Compact()
+	if len(r.pool.nodes) != 0 || len(r.pool.leaves) != 0 {
+		t.Fatalf("pool not released")
+	}
+
+	r = New(WithNodePool(10))
+	for k, v := range inp {
+		r.Insert(k, v)
+	}
+	r.DeletePrefix("")
+	if len(r.pool.nodes) > 10 || len(r.pool.leaves) > 10 {
+		t.Fatalf("pool exceeds max: %v %v", len(r.pool.nodes), len(r.pool.leaves))
+	}
+}
+
+func TestStats(t *testing.T) {
+	r := New()
+	st := r.Stats()
+	if st.Nodes != 1 || st.Leaves != 0 || st.Edges != 0 || st.MaxDepth != 0 {
+		t.Fatalf("bad empty stats: %+v", st)
+	}
+
+	for _, k := range []string{"foo", "foobar", "foobaz", "zip"} {
+		r.Insert(k, nil)
+	}
+	// root -> "foo" -> "ba" -> {"r", "z"}
+	//      -> "zip"
+	st = r.Stats()
+	if st.Nodes != 6 {
+		t.Fatalf("bad nodes: %v", st.Nodes)
+	}
+	if st.Leaves != 4 || st.Edges != 5 {
+		t.Fatalf("bad leaves/edges: %+v", st)
+	}
+	if st.MaxDepth != 3 {
+		t.Fatalf("bad max depth: %v", st.MaxDepth)
+	}
+	if st.AvgDepth != 2 {
+		t.Fatalf("bad avg depth: %v", st.AvgDepth)
+	}
+	if !reflect.DeepEqual(st.FanOut, []int{3, 1, 2}) {
+		t.Fatalf("bad fan out: %v", st.FanOut)
+	}
+	if st.PrefixBytes != 10 || st.KeyBytes != 18 {
+		t.Fatalf("bad bytes: %+v", st)
+	}
+	if st.HeapBytes <= st.KeyBytes {
+		t.Fatalf("bad heap bytes: %v", st.HeapBytes)
+	}
+}
+
+func TestClone(t *testing.T) {
+	r := New()
+	for _, k := range []string{"", "foo", "foobar", "foobaz", "zip"} {
+		r.Insert(k, []int{len(k)})
+	}
+
+	c := r.Clone()
+	if err := c.Validate(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !reflect.DeepEqual(c.ToMap(), r.ToMap()) {
+		t.Fatalf("mis-match: %v %v", c.ToMap(), r.ToMap())
+	}
+
+	// Changes to the clone should not affect the original
+	c.Insert("foobaz", nil)
+	c.Delete("foo")
+	c.DeletePrefix("z")
+	c.Insert("new", nil)
+	if r.Len() != 5 {
+		t.Fatalf("bad len: %v", r.Len())
+	}
+	for _, k := range []string{"", "foo", "foobar", "foobaz", "zip"} {
+		v, ok := r.Get(k)
+		if !ok || v.([]int)[0] != len(k) {
+			t.Fatalf("bad value for %q: %v", k, v)
+		}
+	}
+	if err := r.Validate(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Values are shared by Clone but copied by CloneWith
+	c = r.Clone()
+	v, _ := c.Get("foo")
+	v.([]int)[0] = 100
+	v, _ = r.Get("foo")
+	if v.([]int)[0] != 100 {
+		t.Fatalf("value not shared: %v", v)
+	}
+	c = r.CloneWith(func(v interface{}) interface{} {
+		return append([]int(nil), v.([]int)...)
+	})
+	v, _ = c.Get("foo")
+	v.([]int)[0] = 3
+	v, _ = r.Get("foo")
+	if v.([]int)[0] != 100 {
+		t.Fatalf("value not copied: %v", v)
+	}
+
+	ct := NewConcurrentTree()
+	ct.Insert("foo", 1)
+	cc := ct.Clone()
+	cc.Insert("bar", 2)
+	if ct.Len() != 1 || cc.Len() != 2 {
+		t.Fatalf("bad len: %v %v", ct.Len(), cc.Len())
+	}
+}
+
 func TestLongestPrefix(t *testing.T) {
 	r := New()
 
@@ -829,7 +1175,7 @@ func TestConcurrentTreeOperations(t *testing.T) {
 			sort.Strings(test.out)
 			if !reflect.DeepEqual(out, test.out) {
 				if test.inp != "blackforest" {
-					t.Fatalf("mis-match: %v %v", out, test.out)
+					t.Errorf("mis-match: %v %v", out, test.out)
 				}
 			}
 		}
@@ -856,10 +1202,10 @@ func TestConcurrentTreeOperations(t *testing.T) {
 		defer wg.Done()
 		out, _, found := r.LongestPrefix("a")
 		if out != "a" {
-			t.Fatalf(" failed to Longest get prefix, expected %v, got %v", "a", out)
+			t.Errorf(" failed to Longest get prefix, expected %v, got %v", "a", out)
 		}
 		if !found {
-			t.Fatalf(" failed to find Longest get prefix for %v, expected true", "a")
+			t.Errorf(" failed to find Longest get prefix for %v, expected true", "a")
 		}
 	}()
 
@@ -869,10 +1215,10 @@ func TestConcurrentTreeOperations(t *testing.T) {
 		max, _, _ := r.Maximum()
 		min, _, _ := r.Minimum()
 		if min != "a" {
-			t.Fatalf(" failed to Longest get prefix, expected min  %v, got min %v", "a", min)
+			t.Errorf(" failed to Longest get prefix, expected min  %v, got min %v", "a", min)
 		}
 		if max != "zipzap" {
-			t.Fatalf(" failed to Longest get prefix, expected max  %v, got max %v", "zipzap", max)
+			t.Errorf(" failed to Longest get prefix, expected max  %v, got max %v", "zipzap", max)
 		}
 		r.Len()
 	}()
@@ -900,6 +1246,26 @@ func generateUUID() string {
 		buf[10:16])
 }
 
+func BenchmarkInsertDelete(b *testing.B) {
+	keys := make([]string, 1000)
+	for i := range keys {
+		keys[i] = generateUUID()
+	}
+	bench := func(b *testing.B, r *Tree) {
+		b.ReportAllocs()
+		for n := 0; n < b.N; n++ {
+			for _, k := range keys {
+				r.Insert(k, true)
+			}
+			for _, k := range keys {
+				r.Delete(k)
+			}
+		}
+	}
+	b.Run("default", func(b *testing.B) { bench(b, New()) })
+	b.Run("pooled", func(b *testing.B) { bench(b, New(WithNodePool(0))) })
+}
+
 func BenchmarkInsert(b *testing.B) {
 	b.ReportAllocs()
 	r := New()
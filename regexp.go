@@ -0,0 +1,245 @@
+package radix
+
+import (
+	"reflect"
+	"regexp"
+	"regexp/syntax"
+)
+
+// reMatcher drives a compiled regexp program down the tree
+type reMatcher struct {
+	prog *syntax.Prog
+
+	// seen, stack and out are reused by every closure
+	seen  reSet
+	stack []uint32
+	out   []uint32
+}
+
+// reSet is a sparse set of instructions, cleared in constant time
+type reSet struct {
+	sparse []uint32
+	dense  []uint32
+}
+
+// add is used to add pc to the set, returning false if
+// it was already there
+func (s *reSet) add(pc uint32) bool {
+	i := s.sparse[pc]
+	if i < uint32(len(s.dense)) && s.dense[i] == pc {
+		return false
+	}
+	s.sparse[pc] = uint32(len(s.dense))
+	s.dense = append(s.dense, pc)
+	return true
+}
+
+// reState is the state of the regexp program after matching
+// the path to a node
+type reState struct {
+	// pcs are the threads waiting for the next character,
+	// their empty width instructions are not followed yet
+	pcs []uint32
+
+	// prev is the character before the next one, which empty
+	// width assertions look at, or -1 at the start of the text
+	prev rune
+
+	// pending is the incomplete character left by feedRunes
+	pending string
+
+	// matched is set once a match was found, which then holds
+	// for every key below the node too
+	matched bool
+}
+
+// dead checks if no key below the node can match anymore
+func (m *reMatcher) dead(st *reState) bool {
+	return !st.matched && len(st.pcs) == 0 && st.prev >= 0
+}
+
+// closure follows the non consuming instructions from pcs given the
+// empty width flags, returning the consuming instructions reached
+// and whether a match was reached. The returned slice is only valid
+// until the next call.
+func (m *reMatcher) closure(pcs []uint32, flags syntax.EmptyOp) ([]uint32, bool) {
+	out := m.out[:0]
+	matched := false
+	m.seen.dense = m.seen.dense[:0]
+	stack := append(m.stack[:0], pcs...)
+	for len(stack) > 0 {
+		pc := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if !m.seen.add(pc) {
+			continue
+		}
+		inst := &m.prog.Inst[pc]
+		switch inst.Op {
+		case syntax.InstAlt, syntax.InstAltMatch:
+			stack = append(stack, inst.Arg, inst.Out)
+		case syntax.InstCapture, syntax.InstNop:
+			stack = append(stack, inst.Out)
+		case syntax.InstEmptyWidth:
+			if syntax.EmptyOp(inst.Arg)&^flags == 0 {
+				stack = append(stack, inst.Out)
+			}
+		case syntax.InstMatch:
+			matched = true
+		case syntax.InstRune, syntax.InstRune1, syntax.InstRuneAny, syntax.InstRuneAnyNotNL:
+			out = append(out, pc)
+		}
+	}
+	m.stack, m.out = stack, out
+	return out, matched
+}
+
+// threads returns the threads active before matching the next
+// character, starting the only one at the start of the text
+func (m *reMatcher) threads(st *reState) []uint32 {
+	if st.prev >= 0 {
+		return st.pcs
+	}
+	return []uint32{uint32(m.prog.Start)}
+}
+
+// step advances the state by the character r
+func (m *reMatcher) step(st reState, r rune) reState {
+	pcs, matched := m.closure(m.threads(&st), syntax.EmptyOpContext(st.prev, r))
+	next := reState{prev: r, matched: matched}
+	if matched {
+		return next
+	}
+	for _, pc := range pcs {
+		inst := &m.prog.Inst[pc]
+		ok := false
+		switch inst.Op {
+		case syntax.InstRuneAny:
+			ok = true
+		case syntax.InstRuneAnyNotNL:
+			ok = r != '\n'
+		default:
+			ok = inst.MatchRune(r)
+		}
+		if ok {
+			next.pcs = append(next.pcs, inst.Out)
+		}
+	}
+	return next
+}
+
+// feed returns the state after matching the bytes of text, which
+// stops early once the outcome for the subtree is known
+func (m *reMatcher) feed(st reState, text string) reState {
+	pending := st.pending
+	st.pending = feedRunes(pending, text, false, func(r rune) bool {
+		if st.matched || m.dead(&st) {
+			return false
+		}
+		st = m.step(st, r)
+		return true
+	})
+	return st
+}
+
+// accepts checks if the text matched so far matches
+func (m *reMatcher) accepts(st reState) bool {
+	feedRunes(st.pending, "", true, func(r rune) bool {
+		if st.matched {
+			return false
+		}
+		st = m.step(st, r)
+		return true
+	})
+	if st.matched {
+		return true
+	}
+	_, matched := m.closure(m.threads(&st), syntax.EmptyOpContext(st.prev, -1))
+	return matched
+}
+
+// WalkRegexp is used to walk the keys matching a regular
+// expression, see Tree.WalkRegexp
+func (t *ConcurrentTree) WalkRegexp(re *regexp.Regexp, fn WalkFn) error {
+	t.RLock()
+	defer t.RUnlock()
+	return t.Tree.WalkRegexp(re, fn)
+}
+
+// WalkRegexp is used to walk the keys for which re.MatchString
+// returns true. Regexps anchored with ^ or \A are simulated as an
+// automaton along the tree edges, starting at their literal prefix
+// as reported by re.LiteralPrefix, or at the root if they have none:
+// shared prefixes are only matched once, a subtree is abandoned as
+// soon as no key in it can match, and once a match is found every
+// key below is visited without further matching. Unanchored regexps
+// are checked on every key with re.MatchString, which is faster than
+// the automaton when a match may start anywhere in the key, and so
+// are the ones from regexp.CompilePOSIX. Only expressions that can
+// not be parsed again return an error.
+func (t *Tree) WalkRegexp(re *regexp.Regexp, fn WalkFn) error {
+	if leftmostLongest(re) {
+		t.Walk(func(k string, v interface{}) bool {
+			return re.MatchString(k) && fn(k, v)
+		})
+		return nil
+	}
+
+	// The expression is parsed again with the Perl syntax
+	// of regexp.Compile
+	prefix, _ := re.LiteralPrefix()
+	parsed, err := syntax.Parse(re.String(), syntax.Perl)
+	if err != nil {
+		return err
+	}
+	prog, err := syntax.Compile(parsed.Simplify())
+	if err != nil {
+		return err
+	}
+	if prog.StartCond()&syntax.EmptyBeginText == 0 {
+		t.Walk(func(k string, v interface{}) bool {
+			return re.MatchString(k) && fn(k, v)
+		})
+		return nil
+	}
+
+	n, path := t.findPrefix(prefix)
+	if n == nil {
+		return nil
+	}
+	m := &reMatcher{prog: prog}
+	m.seen.sparse = make([]uint32, len(prog.Inst))
+	m.walk(n, m.feed(reState{prev: -1}, path), fn)
+	return nil
+}
+
+// leftmostLongest checks if re uses leftmost-longest matching, which
+// is set by regexp.CompilePOSIX. Those regexps are parsed with the
+// POSIX syntax, where ^ also matches after a newline and classes
+// never match one, so they can not be parsed again with the Perl
+// syntax. The regexp package does not export the setting, so it is
+// read from the Regexp itself, assuming the worst if it is missing.
+// Regexps on which Longest was called are reported too.
+func leftmostLongest(re *regexp.Regexp) bool {
+	f := reflect.ValueOf(re).Elem().FieldByName("longest")
+	return !f.IsValid() || f.Kind() != reflect.Bool || f.Bool()
+}
+
+// walk is used to visit the matching keys below n, given the
+// threads st left by the path to n
+func (m *reMatcher) walk(n *node, st reState, fn WalkFn) bool {
+	if st.matched {
+		return recursiveWalk(n, fn)
+	}
+	if m.dead(&st) {
+		return false
+	}
+	if n.leaf != nil && m.accepts(st) && fn(n.leaf.key, n.leaf.val) {
+		return true
+	}
+	for _, e := range n.edges {
+		if m.walk(e.node, m.feed(st, e.node.prefix), fn) {
+			return true
+		}
+	}
+	return false
+}
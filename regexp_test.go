@@ -0,0 +1,155 @@
+package radix
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+func TestWalkRegexp(t *testing.T) {
+	keys := []string{
+		"", "audit/2021/01", "audit/2021/02", "audit/2022/01", "audit/x", "foo", "foo\nbar",
+		"foo bar", "foobar", "user-1", "user-12", "user-2a", "userx", "日本", "日本語", "旧",
+	}
+	r := NewConcurrentTree()
+	for _, k := range keys {
+		r.Insert(k, nil)
+	}
+
+	patterns := []string{
+		``, `^`, `$`, `^$`, `.`, `^audit/\d{4}/0[12]$`, `^audit/`, `2021`, `^user-\d+$`,
+		`user-\d`, `(?i)^FOO`, `bar$`, `(?m)^bar`, `\bbar`, `^foo.bar$`, `(?s)^foo.bar$`,
+		`^日本.`, `^.$`, `本`, `^(foo|user)`, `^[a-f]+$`, `x$`, `^nope`, `o\b`,
+	}
+	for _, p := range patterns {
+		re := regexp.MustCompile(p)
+		exp := []string{}
+		for _, k := range keys {
+			if re.MatchString(k) {
+				exp = append(exp, k)
+			}
+		}
+		out := []string{}
+		err := r.WalkRegexp(re, func(k string, v interface{}) bool {
+			out = append(out, k)
+			return false
+		})
+		if err != nil {
+			t.Fatalf("%q: %v", p, err)
+		}
+		if !reflect.DeepEqual(out, exp) {
+			t.Fatalf("%q: mis-match: %q %q", p, out, exp)
+		}
+	}
+
+	// Stop early
+	out := []string{}
+	r.WalkRegexp(regexp.MustCompile(`^audit`), func(k string, v interface{}) bool {
+		out = append(out, k)
+		return len(out) == 2
+	})
+	if !reflect.DeepEqual(out, []string{"audit/2021/01", "audit/2021/02"}) {
+		t.Fatalf("bad walk: %v", out)
+	}
+}
+
+func TestWalkRegexpPrunes(t *testing.T) {
+	r := New()
+	for _, k := range []string{"a/1/x", "a/2/x", "a/2/y", "b/1"} {
+		r.Insert(k, nil)
+	}
+	re := regexp.MustCompile(`^a/\d/x`)
+
+	// Walking an anchored regexp with a literal prefix must never
+	// look at the leaves of the a/2/y subtree, which would report
+	// the planted key
+	n, _ := r.findPrefix("a/2/y")
+	n.leaf.key = "a/1/x"
+	out := []string{}
+	r.WalkRegexp(re, func(k string, v interface{}) bool {
+		out = append(out, k)
+		return false
+	})
+	if !reflect.DeepEqual(out, []string{"a/1/x", "a/2/x"}) {
+		t.Fatalf("bad walk: %v", out)
+	}
+}
+
+func TestWalkRegexpPrunesFromRoot(t *testing.T) {
+	r := New()
+	for _, k := range []string{"a/1/x", "b/2/x", "b/2/y", "c/1"} {
+		r.Insert(k, nil)
+	}
+	re := regexp.MustCompile(`^[ab]/\d/x`)
+
+	// Anchored regexps without a literal prefix are walked from
+	// the root and must never look at the leaves of b/2/y either
+	n, _ := r.findPrefix("b/2/y")
+	n.leaf.key = "a/1/x"
+	out := []string{}
+	r.WalkRegexp(re, func(k string, v interface{}) bool {
+		out = append(out, k)
+		return false
+	})
+	if !reflect.DeepEqual(out, []string{"a/1/x", "b/2/x"}) {
+		t.Fatalf("bad walk: %v", out)
+	}
+}
+
+func TestWalkRegexpPOSIX(t *testing.T) {
+	r := New()
+	for _, k := range []string{"foo", "x\nfoo", "xfoo"} {
+		r.Insert(k, nil)
+	}
+	for _, re := range []*regexp.Regexp{
+		regexp.MustCompilePOSIX(`^foo`),
+		regexp.MustCompilePOSIX(`^x.foo$`),
+		regexp.MustCompilePOSIX(`[^a-z]foo`),
+		regexp.MustCompilePOSIX(`^[^a-z]*foo`),
+	} {
+		exp := []string{}
+		r.Walk(func(k string, v interface{}) bool {
+			if re.MatchString(k) {
+				exp = append(exp, k)
+			}
+			return false
+		})
+		out := []string{}
+		r.WalkRegexp(re, func(k string, v interface{}) bool {
+			out = append(out, k)
+			return false
+		})
+		if !reflect.DeepEqual(out, exp) {
+			t.Fatalf("%v: mis-match: %q %q", re, out, exp)
+		}
+	}
+}
+
+func BenchmarkWalkRegexp(b *testing.B) {
+	r := New()
+	for i := 0; i < 100000; i++ {
+		r.Insert(fmt.Sprintf("user-%d/item-%d", i/100, i%100), nil)
+	}
+	patterns := []string{`^user-12/`, `^user-1\d/item-\d$`, `^user-\d+/item-99$`, `item-99$`}
+	for _, p := range patterns {
+		re := regexp.MustCompile(p)
+		b.Run(p, func(b *testing.B) {
+			b.ReportAllocs()
+			for n := 0; n < b.N; n++ {
+				r.WalkRegexp(re, func(k string, v interface{}) bool {
+					return false
+				})
+			}
+		})
+		b.Run(p+"/walk", func(b *testing.B) {
+			b.ReportAllocs()
+			for n := 0; n < b.N; n++ {
+				r.Walk(func(k string, v interface{}) bool {
+					re.MatchString(k)
+					return false
+				})
+			}
+		})
+	}
+}
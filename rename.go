@@ -0,0 +1,99 @@
+package radix
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrKeyExists is returned when an operation would overwrite
+// an existing key without being allowed to
+var ErrKeyExists = errors.New("radix: key already exists")
+
+// RenamePrefix is used to atomically move every entry under
+// oldPrefix to newPrefix, see Tree.RenamePrefix
+func (t *ConcurrentTree) RenamePrefix(oldPrefix, newPrefix string, overwrite bool) (int, error) {
+	t.Lock()
+	defer t.Unlock()
+	return t.Tree.RenamePrefix(oldPrefix, newPrefix, overwrite)
+}
+
+// RenamePrefix is used to move every entry under oldPrefix to
+// newPrefix, so "cust/old/name" becomes "cust/new/name". The
+// subtree is detached and merged with the entries under newPrefix
+// in a single pass, moving its nodes instead of inserting its keys.
+// Only the keys stored in the leaves are rewritten. If a renamed key
+// already exists outside the moved subtree, an error wrapping
+// ErrKeyExists is returned and the tree is left unchanged, unless
+// overwrite is set in which case the existing entry is replaced.
+// Collisions are found by walking the moved subtree and the
+// destination together. Returns how many entries were moved.
+func (t *Tree) RenamePrefix(oldPrefix, newPrefix string, overwrite bool) (int, error) {
+	if oldPrefix == newPrefix {
+		n, _ := t.findPrefix(oldPrefix)
+		moved := 0
+		recursiveWalk(n, func(string, interface{}) bool {
+			moved++
+			return false
+		})
+		return moved, nil
+	}
+
+	n, path := t.detachPrefix(oldPrefix)
+	if n == nil {
+		return 0, nil
+	}
+	newPath := newPrefix + path[len(oldPrefix):]
+
+	// Check for collisions with the remaining entries, only
+	// following the paths found on both sides
+	if !overwrite {
+		if l := t.firstCollision(n, newPath); l != nil {
+			t.attach(n, path)
+			return 0, fmt.Errorf("%w: %q", ErrKeyExists, l.key)
+		}
+	}
+
+	// Rewrite the keys in place
+	moved := 0
+	walkLeaves(n, func(l *leafNode) bool {
+		l.key = newPrefix + l.key[len(oldPrefix):]
+		moved++
+		return false
+	})
+	t.size -= t.attach(n, newPath)
+	return moved, nil
+}
+
+// firstCollision returns the leaf of the tree that has the same
+// key as a leaf under n once n is placed at path, or nil
+func (t *Tree) firstCollision(n *node, path string) *leafNode {
+	return collision(t.root, "", placeAt(n, path), "")
+}
+
+// collision is used to find a leaf of a with the same key as a
+// leaf of b, where pa and pb are the remaining prefixes of a and b
+// starting at the same position. Only the children found on both
+// sides are visited.
+func collision(a *node, pa string, b *node, pb string) *leafNode {
+	c := longestPrefix(pa, pb)
+	la, ca := children(a, pa, c)
+	lb, cb := children(b, pb, c)
+	if la != nil && lb != nil {
+		return la
+	}
+	for i, j := 0, 0; i < len(ca) && j < len(cb); {
+		switch {
+		case ca[i].prefix[0] < cb[j].prefix[0]:
+			i++
+		case cb[j].prefix[0] < ca[i].prefix[0]:
+			j++
+		default:
+			if l := collision(ca[i].n, ca[i].prefix, cb[j].n, cb[j].prefix); l != nil {
+				return l
+			}
+			i++
+			j++
+		}
+	}
+	return nil
+}
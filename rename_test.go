@@ -0,0 +1,106 @@
+package radix
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestRenamePrefix(t *testing.T) {
+	keys := []string{"cust/old/", "cust/old/name", "cust/old/zone", "cust/older", "cust/other", "zip"}
+	build := func() *Tree {
+		r := New()
+		for _, k := range keys {
+			r.Insert(k, k)
+		}
+		return r
+	}
+
+	type exp struct {
+		old, new  string
+		overwrite bool
+		moved     int
+		out       []string
+	}
+	cases := []exp{
+		{"cust/old/", "cust/new/", false, 3, []string{"cust/new/", "cust/new/name", "cust/new/zone", "cust/older", "cust/other", "zip"}},
+		{"cust/old", "cust/new", false, 4, []string{"cust/new/", "cust/new/name", "cust/new/zone", "cust/newer", "cust/other", "zip"}},
+		{"cust/old/n", "x", false, 1, []string{"cust/old/", "cust/old/zone", "cust/older", "cust/other", "xame", "zip"}},
+		{"cust/", "", false, 5, []string{"old/", "old/name", "old/zone", "older", "other", "zip"}},
+		{"", "pre/", false, 6, []string{"pre/cust/old/", "pre/cust/old/name", "pre/cust/old/zone", "pre/cust/older", "pre/cust/other", "pre/zip"}},
+		{"cust/old/", "cust/old/sub/", false, 3, []string{"cust/old/sub/", "cust/old/sub/name", "cust/old/sub/zone", "cust/older", "cust/other", "zip"}},
+		{"cust/old/", "cust/", true, 3, []string{"cust/", "cust/name", "cust/older", "cust/other", "cust/zone", "zip"}},
+		{"cust/other", "zip", true, 1, []string{"cust/old/", "cust/old/name", "cust/old/zone", "cust/older", "zip"}},
+		{"missing", "cust/", false, 0, keys},
+		{"cust/old", "cust/old", false, 4, keys},
+	}
+	for _, test := range cases {
+		r := build()
+		moved, err := r.RenamePrefix(test.old, test.new, test.overwrite)
+		if err != nil {
+			t.Fatalf("%q -> %q: %v", test.old, test.new, err)
+		}
+		if moved != test.moved {
+			t.Fatalf("%q -> %q: bad moved: %v %v", test.old, test.new, moved, test.moved)
+		}
+		if err := r.Validate(); err != nil {
+			t.Fatalf("%q -> %q: %v", test.old, test.new, err)
+		}
+		out := []string{}
+		r.Walk(func(k string, v interface{}) bool {
+			out = append(out, k)
+			return false
+		})
+		if !reflect.DeepEqual(out, test.out) {
+			t.Fatalf("%q -> %q: mis-match: %v %v", test.old, test.new, out, test.out)
+		}
+	}
+
+	// Values move along with the keys
+	r := build()
+	r.RenamePrefix("cust/other", "zip", true)
+	if v, _ := r.Get("zip"); v != "cust/other" {
+		t.Fatalf("bad value: %v", v)
+	}
+
+	// Collisions leave the tree unchanged
+	r = build()
+	r.Insert("cust/name", "cust/name")
+	before := r.ToMap()
+	moved, err := r.RenamePrefix("cust/old/", "cust/", false)
+	if !errors.Is(err, ErrKeyExists) || moved != 0 {
+		t.Fatalf("expected collision: %v %v", moved, err)
+	}
+	if err := r.Validate(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out := r.ToMap(); !reflect.DeepEqual(out, before) {
+		t.Fatalf("mis-match: %v %v", out, before)
+	}
+
+	// Moving the whole tree under a prefix keeps it compressed
+	// when the root only has one child
+	r = New()
+	r.Insert("cust/a", 1)
+	r.Insert("cust/b", 2)
+	if moved, err := r.RenamePrefix("", "x/", false); err != nil || moved != 2 {
+		t.Fatalf("bad rename: %v %v", moved, err)
+	}
+	if err := r.Validate(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if exp := map[string]interface{}{"x/cust/a": 1, "x/cust/b": 2}; !reflect.DeepEqual(r.ToMap(), exp) {
+		t.Fatalf("mis-match: %v %v", r.ToMap(), exp)
+	}
+
+	// Leaves are moved into a populated destination, not copied
+	r = build()
+	n, _ := r.findPrefix("cust/old/name")
+	moved, err = r.RenamePrefix("cust/old/", "cust/", true)
+	if err != nil || moved != 3 {
+		t.Fatalf("bad rename: %v %v", moved, err)
+	}
+	if m, _ := r.findPrefix("cust/name"); m.leaf != n.leaf {
+		t.Fatalf("leaf was copied")
+	}
+}
@@ -0,0 +1,259 @@
+package radix
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	// ErrInvalidPattern is returned when a route pattern
+	// can not be parsed
+	ErrInvalidPattern = errors.New("radix: invalid route pattern")
+
+	// ErrRouteConflict is returned when a route pattern
+	// is ambiguous with a route that was already inserted
+	ErrRouteConflict = errors.New("radix: conflicting route")
+)
+
+// Param is a named parameter extracted from a path by a Router
+type Param struct {
+	Key   string
+	Value string
+}
+
+// Params is the list of parameters extracted from a path,
+// in the order they appear in the route pattern
+type Params []Param
+
+// Get returns the value of the named parameter
+func (p Params) Get(key string) (string, bool) {
+	for _, param := range p {
+		if param.Key == key {
+			return param.Value, true
+		}
+	}
+	return "", false
+}
+
+// Router is a route table matching paths against patterns with
+// named parameters and catch-all segments. Patterns are split
+// into segments on '/'. A segment of the form ":name" matches
+// any single segment, and a final segment of the form "*name"
+// matches the rest of the path, including any further '/'. For
+// example "/users/:id/posts/*path" matches "/users/42/posts/a/b"
+// with the parameters id=42 and path=a/b.
+//
+// When matching, static segments take priority over parameters,
+// which take priority over catch-alls. A Router is not safe for
+// concurrent use.
+type Router struct {
+	root *routeNode
+	size int
+}
+
+// routeNode is a single segment level of the router
+type routeNode struct {
+	// static maps the literal next segments to their nodes
+	static map[string]*routeNode
+
+	// param is the node for a ":name" next segment
+	param *routeNode
+
+	// catchAll is the node for a "*name" final segment
+	catchAll *routeNode
+
+	// name is the parameter name of a param or catch-all node
+	name string
+
+	// route is set if a pattern ends at this node
+	route *route
+}
+
+// route is a pattern stored in the router
+type route struct {
+	pattern string
+	val     interface{}
+}
+
+// NewRouter returns an empty Router
+func NewRouter() *Router {
+	return &Router{root: &routeNode{}}
+}
+
+// Len is used to return the number of routes in the router
+func (r *Router) Len() int {
+	return r.size
+}
+
+// routeSegment is a parsed segment of a route pattern
+type routeSegment struct {
+	// kind is 0 for a static segment, or ':' or '*'
+	kind byte
+
+	// text is the literal segment or the parameter name
+	text string
+}
+
+// Insert is used to add a route pattern with its value. An error
+// wrapping ErrInvalidPattern is returned for malformed patterns,
+// ErrKeyExists if the pattern was already inserted, and
+// ErrRouteConflict if a parameter at the same position of another
+// pattern has a different name. The router is left unchanged when
+// an error is returned.
+func (r *Router) Insert(pattern string, v interface{}) error {
+	segs, err := parseRoute(pattern)
+	if err != nil {
+		return err
+	}
+
+	// Check the existing nodes before creating any
+	n := r.root
+	for _, seg := range segs {
+		if n = n.child(seg, false); n == nil {
+			break
+		}
+		if seg.kind != 0 && n.name != seg.text {
+			return fmt.Errorf("%w: %q in %q conflicts with %c%s", ErrRouteConflict, string(seg.kind)+seg.text, pattern, seg.kind, n.name)
+		}
+	}
+	if n != nil && n.route != nil {
+		return fmt.Errorf("%w: %q conflicts with %q", ErrKeyExists, pattern, n.route.pattern)
+	}
+
+	n = r.root
+	for _, seg := range segs {
+		n = n.child(seg, true)
+	}
+	n.route = &route{pattern: pattern, val: v}
+	r.size++
+	return nil
+}
+
+// parseRoute is used to split a pattern into its segments,
+// checking the parameter names and the catch-all position
+func parseRoute(pattern string) ([]routeSegment, error) {
+	var segs []routeSegment
+	names := make(map[string]struct{})
+	rest := pattern
+	for more := true; more; {
+		var seg string
+		seg, rest, more = cutSegment(rest)
+		if !strings.HasPrefix(seg, ":") && !strings.HasPrefix(seg, "*") {
+			segs = append(segs, routeSegment{text: seg})
+			continue
+		}
+
+		name := seg[1:]
+		if name == "" {
+			return nil, fmt.Errorf("%w: empty parameter name in %q", ErrInvalidPattern, pattern)
+		}
+		if _, ok := names[name]; ok {
+			return nil, fmt.Errorf("%w: duplicate parameter %q in %q", ErrInvalidPattern, name, pattern)
+		}
+		if seg[0] == '*' && more {
+			return nil, fmt.Errorf("%w: catch-all %q must be the last segment of %q", ErrInvalidPattern, seg, pattern)
+		}
+		names[name] = struct{}{}
+		segs = append(segs, routeSegment{kind: seg[0], text: name})
+	}
+	return segs, nil
+}
+
+// child returns the node for the next segment below n, creating
+// it if create is set, or nil if it does not exist
+func (n *routeNode) child(seg routeSegment, create bool) *routeNode {
+	switch seg.kind {
+	case ':', '*':
+		child := &n.param
+		if seg.kind == '*' {
+			child = &n.catchAll
+		}
+		if *child == nil && create {
+			*child = &routeNode{name: seg.text}
+		}
+		return *child
+
+	default:
+		if n.static == nil {
+			if !create {
+				return nil
+			}
+			n.static = make(map[string]*routeNode)
+		}
+		child, ok := n.static[seg.text]
+		if !ok {
+			if !create {
+				return nil
+			}
+			child = &routeNode{}
+			n.static[seg.text] = child
+		}
+		return child
+	}
+}
+
+// Match is used to find the route matching a path, returning its
+// value and the parameters extracted from the path
+func (r *Router) Match(path string) (interface{}, Params, bool) {
+	rt, params := r.root.match(path, nil)
+	if rt == nil {
+		return nil, nil, false
+	}
+	return rt.val, params, true
+}
+
+// MatchPattern is like Match, but also returns the pattern
+// of the matching route
+func (r *Router) MatchPattern(path string) (string, interface{}, Params, bool) {
+	rt, params := r.root.match(path, nil)
+	if rt == nil {
+		return "", nil, nil, false
+	}
+	return rt.pattern, rt.val, params, true
+}
+
+// match recursively looks for the route matching the segments
+// in path below n, trying static segments first, then parameters
+// and finally catch-alls. Parameters never match an empty segment.
+func (n *routeNode) match(path string, params Params) (*route, Params) {
+	seg, rest, more := cutSegment(path)
+
+	if child, ok := n.static[seg]; ok {
+		if rt, out := child.next(rest, more, params); rt != nil {
+			return rt, out
+		}
+	}
+
+	if n.param != nil && seg != "" {
+		p := append(params, Param{Key: n.param.name, Value: seg})
+		if rt, out := n.param.next(rest, more, p); rt != nil {
+			return rt, out
+		}
+	}
+
+	if n.catchAll != nil && n.catchAll.route != nil {
+		return n.catchAll.route, append(params, Param{Key: n.catchAll.name, Value: path})
+	}
+	return nil, nil
+}
+
+// next continues matching below n once a segment was consumed
+func (n *routeNode) next(rest string, more bool, params Params) (*route, Params) {
+	if !more {
+		if n.route != nil {
+			return n.route, params
+		}
+		return nil, nil
+	}
+	return n.match(rest, params)
+}
+
+// cutSegment splits the first segment off s, returning it along
+// with the remainder and whether there was a '/' to split on
+func cutSegment(s string) (string, string, bool) {
+	if i := strings.IndexByte(s, '/'); i >= 0 {
+		return s[:i], s[i+1:], true
+	}
+	return s, "", false
+}
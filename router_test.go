@@ -0,0 +1,131 @@
+package radix
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestRouter(t *testing.T) {
+	r := NewRouter()
+	patterns := []string{
+		"/",
+		"/users",
+		"/users/new",
+		"/users/:id",
+		"/users/:id/posts",
+		"/users/:id/posts/:post",
+		"/users/:id/posts/*path",
+		"/files/*path",
+		"/:lang/about",
+	}
+	for _, p := range patterns {
+		if err := r.Insert(p, p); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+	if r.Len() != len(patterns) {
+		t.Fatalf("bad len: %v", r.Len())
+	}
+
+	type exp struct {
+		inp     string
+		pattern string
+		params  Params
+	}
+	cases := []exp{
+		{"/", "/", nil},
+		{"/users", "/users", nil},
+		{"/users/new", "/users/new", nil},
+		{"/users/42", "/users/:id", Params{{"id", "42"}}},
+		{"/users/42/posts", "/users/:id/posts", Params{{"id", "42"}}},
+		{"/users/42/posts/7", "/users/:id/posts/:post", Params{{"id", "42"}, {"post", "7"}}},
+		{"/users/42/posts/7/comments", "/users/:id/posts/*path", Params{{"id", "42"}, {"path", "7/comments"}}},
+		{"/users/42/posts/", "/users/:id/posts/*path", Params{{"id", "42"}, {"path", ""}}},
+		{"/users/new/posts", "/users/:id/posts", Params{{"id", "new"}}},
+		{"/files/a/b/c.txt", "/files/*path", Params{{"path", "a/b/c.txt"}}},
+		{"/en/about", "/:lang/about", Params{{"lang", "en"}}},
+		{"/users/about", "/users/:id", Params{{"id", "about"}}},
+		{"/blog/about", "/:lang/about", Params{{"lang", "blog"}}},
+		{"/users/", "", nil},
+		{"/users/42/other", "", nil},
+		{"/files", "", nil},
+		{"/en", "", nil},
+		{"users", "", nil},
+	}
+	for _, test := range cases {
+		pattern, v, params, ok := r.MatchPattern(test.inp)
+		if test.pattern == "" {
+			if ok {
+				t.Fatalf("%q: unexpected match %q", test.inp, pattern)
+			}
+			continue
+		}
+		if !ok || pattern != test.pattern || v != test.pattern {
+			t.Fatalf("%q: mis-match: %q %v", test.inp, pattern, test.pattern)
+		}
+		if !reflect.DeepEqual(params, test.params) {
+			t.Fatalf("%q: bad params: %v %v", test.inp, params, test.params)
+		}
+	}
+
+	v, params, ok := r.Match("/users/42/posts/7")
+	if !ok || v != "/users/:id/posts/:post" {
+		t.Fatalf("bad match: %v", v)
+	}
+	if id, ok := params.Get("id"); !ok || id != "42" {
+		t.Fatalf("bad param: %v", id)
+	}
+	if _, ok := params.Get("nope"); ok {
+		t.Fatalf("unexpected param")
+	}
+}
+
+func TestRouterConflicts(t *testing.T) {
+	r := NewRouter()
+	for _, p := range []string{"/users/:id", "/files/*path"} {
+		if err := r.Insert(p, nil); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+
+	type exp struct {
+		pattern string
+		err     error
+	}
+	cases := []exp{
+		{"/users/:id", ErrKeyExists},
+		{"/users/:name", ErrRouteConflict},
+		{"/users/:name/posts", ErrRouteConflict},
+		{"/files/*rest", ErrRouteConflict},
+		{"/files/*path/more", ErrInvalidPattern},
+		{"/users/:", ErrInvalidPattern},
+		{"/*", ErrInvalidPattern},
+		{"/:a/:a", ErrInvalidPattern},
+	}
+	for _, test := range cases {
+		if err := r.Insert(test.pattern, nil); !errors.Is(err, test.err) {
+			t.Fatalf("%q: expected %v got %v", test.pattern, test.err, err)
+		}
+	}
+	if r.Len() != 2 {
+		t.Fatalf("bad len: %v", r.Len())
+	}
+
+	// Rejected patterns leave nothing behind
+	if err := r.Insert("/newroute/:", nil); !errors.Is(err, ErrInvalidPattern) {
+		t.Fatalf("expected %v got %v", ErrInvalidPattern, err)
+	}
+	if r.root.param != nil {
+		t.Fatalf("param node left behind: %v", r.root.param.name)
+	}
+	if _, ok := r.root.static["newroute"]; ok {
+		t.Fatalf("static node left behind")
+	}
+	if err := r.Insert("/:lang/about", "/:lang/about"); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if v, params, ok := r.Match("/en/about"); !ok || v != "/:lang/about" || params[0].Value != "en" {
+		t.Fatalf("bad match: %v %v %v", v, params, ok)
+	}
+}
@@ -0,0 +1,38 @@
+package radix
+
+import "unicode/utf8"
+
+// nextRune decodes the first character of s like
+// utf8.DecodeRuneInString, but returns false if s only holds the
+// start of a character that more bytes may complete. Once the text
+// has ended final is set, and such bytes decode as utf8.RuneError
+// one at a time. This is shared by the matchers that walk the tree
+// one character at a time, as node prefixes may split a character.
+func nextRune(s string, final bool) (rune, int, bool) {
+	if !final && !utf8.FullRuneInString(s) {
+		return 0, 0, false
+	}
+	r, size := utf8.DecodeRuneInString(s)
+	return r, size, true
+}
+
+// feedRunes is used to pass the characters of pending followed
+// by text to step, until step returns false. Returns the bytes of
+// an incomplete trailing character, which are passed as pending
+// along with the next text, or with final set at the end of a key.
+func feedRunes(pending, text string, final bool, step func(r rune) bool) string {
+	if pending != "" {
+		text = pending + text
+	}
+	for len(text) > 0 {
+		r, size, ok := nextRune(text, final)
+		if !ok {
+			return text
+		}
+		if !step(r) {
+			return ""
+		}
+		text = text[size:]
+	}
+	return ""
+}
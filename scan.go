@@ -0,0 +1,220 @@
+package radix
+
+// ScanFn is used when scanning text with Scan. Takes the byte
+// offsets of an occurrence in the text along with the key found
+// there and its value, returning if scanning should be terminated.
+type ScanFn func(start, end int, key string, v interface{}) bool
+
+// Scanner is an Aho-Corasick automaton compiled from the keys of
+// a tree, used to find every occurrence of the keys inside a text
+// in a single pass. It is a snapshot of the tree when it was built,
+// later changes to the tree are not seen. A Scanner is safe for
+// concurrent use.
+//
+// Every byte of every node prefix is a state, the position reached
+// after reading it. The states are held in flat arrays, numbered
+// node by node in breadth first order, so the states of a node are
+// consecutive and so are the nodes of its children.
+type Scanner struct {
+	// label is the byte leading into each state, owner the node
+	// the state belongs to, fail the state for the longest proper
+	// suffix of its position that is also a position, and dict the
+	// nearest state along the fail links ending a key, or -1
+	label []byte
+	owner []int32
+	fail  []int32
+	dict  []int32
+
+	// start is the first state of each node, and child its first
+	// child, both with a trailing entry so the range of node i
+	// ends at the value for i+1. State 0 is the root.
+	start []int32
+	child []int32
+
+	// leaf is the index into keys and vals of the key ending at
+	// each node, or -1
+	leaf []int32
+	keys []string
+	vals []interface{}
+}
+
+// Scanner is used to compile the keys of the tree into
+// a Scanner while holding the read lock
+func (t *ConcurrentTree) Scanner() *Scanner {
+	t.RLock()
+	defer t.RUnlock()
+	return t.Tree.Scanner()
+}
+
+// Scanner is used to compile the keys of the tree into a Scanner.
+// Building it is linear in the size of the tree, and takes a few
+// bytes per byte of node prefix. Use this to search many texts for
+// the same keys.
+func (t *Tree) Scanner() *Scanner {
+	sc := &Scanner{}
+	queue := []*node{t.root}
+	for i := 0; i < len(queue); i++ {
+		n := queue[i]
+		sc.start = append(sc.start, int32(len(sc.label)))
+		sc.child = append(sc.child, int32(len(queue)))
+		if i == 0 {
+			sc.label = append(sc.label, 0)
+			sc.owner = append(sc.owner, 0)
+		}
+		for j := 0; j < len(n.prefix); j++ {
+			sc.label = append(sc.label, n.prefix[j])
+			sc.owner = append(sc.owner, int32(i))
+		}
+		if n.leaf != nil {
+			sc.leaf = append(sc.leaf, int32(len(sc.keys)))
+			sc.keys = append(sc.keys, n.leaf.key)
+			sc.vals = append(sc.vals, n.leaf.val)
+		} else {
+			sc.leaf = append(sc.leaf, -1)
+		}
+		for _, e := range n.edges {
+			queue = append(queue, e.node)
+		}
+	}
+	sc.start = append(sc.start, int32(len(sc.label)))
+	sc.child = append(sc.child, int32(len(queue)))
+	sc.link()
+	return sc
+}
+
+// isEnd checks if id is the last state of its node
+func (sc *Scanner) isEnd(id int32) bool {
+	return id == sc.start[sc.owner[id]+1]-1
+}
+
+// isKey checks if a key other than the empty one ends at id
+func (sc *Scanner) isKey(id int32) bool {
+	return id > 0 && sc.isEnd(id) && sc.leaf[sc.owner[id]] >= 0
+}
+
+// goTo returns the state reached from id by b, or -1
+func (sc *Scanner) goTo(id int32, b byte) int32 {
+	if !sc.isEnd(id) {
+		if sc.label[id+1] == b {
+			return id + 1
+		}
+		return -1
+	}
+
+	// Look for the child whose first state is labeled b
+	n := sc.owner[id]
+	lo, hi := sc.child[n], sc.child[n+1]
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+		if sc.label[sc.start[mid]] < b {
+			lo = mid + 1
+		} else {
+			hi = mid
+		}
+	}
+	if lo < sc.child[n+1] && sc.label[sc.start[lo]] == b {
+		return sc.start[lo]
+	}
+	return -1
+}
+
+// next is used to call fn with every state reached from id by
+// a single byte, in order
+func (sc *Scanner) next(id int32, fn func(child int32)) {
+	if !sc.isEnd(id) {
+		fn(id + 1)
+		return
+	}
+	n := sc.owner[id]
+	for c := sc.child[n]; c < sc.child[n+1]; c++ {
+		fn(sc.start[c])
+	}
+}
+
+// link is used to compute the fail and dict links breadth first,
+// so the links of shorter positions are known when they are needed
+func (sc *Scanner) link() {
+	sc.fail = make([]int32, len(sc.label))
+	sc.dict = make([]int32, len(sc.label))
+	sc.dict[0] = -1
+	queue := []int32{0}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		sc.next(id, func(child int32) {
+			queue = append(queue, child)
+			b := sc.label[child]
+
+			fail := int32(0)
+			if id != 0 {
+				for f := sc.fail[id]; ; f = sc.fail[f] {
+					if next := sc.goTo(f, b); next >= 0 {
+						fail = next
+						break
+					}
+					if f == 0 {
+						break
+					}
+				}
+			}
+			sc.fail[child] = fail
+			if sc.isKey(fail) {
+				sc.dict[child] = fail
+			} else {
+				sc.dict[child] = sc.dict[fail]
+			}
+		})
+	}
+}
+
+// Scan is used to report every occurrence of the keys in text, see
+// Tree.Scan
+func (sc *Scanner) Scan(text string, fn ScanFn) {
+	cur := int32(0)
+	for i := 0; i < len(text); i++ {
+		b := text[i]
+		for {
+			if next := sc.goTo(cur, b); next >= 0 {
+				cur = next
+				break
+			}
+			if cur == 0 {
+				break
+			}
+			cur = sc.fail[cur]
+		}
+
+		end := i + 1
+		id := cur
+		if !sc.isKey(id) {
+			id = sc.dict[id]
+		}
+		for ; id > 0; id = sc.dict[id] {
+			l := sc.leaf[sc.owner[id]]
+			key := sc.keys[l]
+			if fn(end-len(key), end, key, sc.vals[l]) {
+				return
+			}
+		}
+	}
+}
+
+// Scan is used to report every occurrence of the keys of the tree
+// in text, see Tree.Scan. The read lock is only held while the
+// Scanner is built, so fn may modify the tree.
+func (t *ConcurrentTree) Scan(text string, fn ScanFn) {
+	t.Scanner().Scan(text, fn)
+}
+
+// Scan is used to report every occurrence of the keys of the tree
+// inside text, including overlapping ones, in a single pass over
+// the text. Occurrences are reported by increasing end offset, and
+// from the longest to the shortest for the same end. The empty key
+// is never reported. Offsets are in bytes. A Scanner is built for
+// every call, which is linear in the size of the tree, so use
+// Tree.Scanner instead to search many texts for the same keys.
+// The keys are matched as they were when Scan was called, so fn
+// may modify the tree.
+func (t *Tree) Scan(text string, fn ScanFn) {
+	t.Scanner().Scan(text, fn)
+}
@@ -0,0 +1,131 @@
+package radix
+
+import (
+	"math/rand"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// scanMatch is a single occurrence reported by Scan
+type scanMatch struct {
+	start, end int
+	key        string
+}
+
+// scan is a plain implementation of Scan trying every key at
+// every end offset
+func scan(keys []string, text string) []scanMatch {
+	var out []scanMatch
+	for end := 1; end <= len(text); end++ {
+		var found []scanMatch
+		for _, k := range keys {
+			if k != "" && strings.HasSuffix(text[:end], k) {
+				found = append(found, scanMatch{end - len(k), end, k})
+			}
+		}
+		// Longest first for the same end
+		sort.Slice(found, func(i, j int) bool {
+			return found[i].start < found[j].start
+		})
+		out = append(out, found...)
+	}
+	return out
+}
+
+func TestScan(t *testing.T) {
+	keys := []string{"", "he", "she", "his", "hers", "s", "日本", "本語"}
+	r := NewConcurrentTree()
+	for _, k := range keys {
+		r.Insert(k, len(k))
+	}
+
+	text := "ushers say 日本語 his"
+	var out []scanMatch
+	r.Scan(text, func(start, end int, k string, v interface{}) bool {
+		if v != len(k) || text[start:end] != k {
+			t.Fatalf("bad match: %d %d %q %v", start, end, k, v)
+		}
+		out = append(out, scanMatch{start, end, k})
+		return false
+	})
+	exp := []scanMatch{
+		{1, 2, "s"},
+		{1, 4, "she"},
+		{2, 4, "he"},
+		{2, 6, "hers"},
+		{5, 6, "s"},
+		{7, 8, "s"},
+		{11, 17, "日本"},
+		{14, 20, "本語"},
+		{21, 24, "his"},
+		{23, 24, "s"},
+	}
+	if !reflect.DeepEqual(out, exp) {
+		t.Fatalf("mis-match: %v %v", out, exp)
+	}
+
+	// Stop early
+	num := 0
+	r.Scan(text, func(start, end int, k string, v interface{}) bool {
+		num++
+		return num == 3
+	})
+	if num != 3 {
+		t.Fatalf("bad count: %d", num)
+	}
+
+	// Empty tree
+	New().Scan(text, func(start, end int, k string, v interface{}) bool {
+		t.Fatalf("unexpected match: %q", k)
+		return false
+	})
+}
+
+func TestScanner(t *testing.T) {
+	rng := rand.New(rand.NewSource(7))
+	word := func(max int) string {
+		b := make([]byte, 1+rng.Intn(max))
+		for i := range b {
+			b[i] = "ab"[rng.Intn(2)]
+		}
+		return string(b)
+	}
+
+	for i := 0; i < 50; i++ {
+		r := New()
+		var keys []string
+		for j := 0; j < 1+rng.Intn(20); j++ {
+			k := word(6)
+			if _, ok := r.Insert(k, nil); !ok {
+				keys = append(keys, k)
+			}
+		}
+		sc := r.Scanner()
+		texts := make([]string, 5)
+		for j := range texts {
+			texts[j] = word(40)
+		}
+		check := func(text string, scan func(string, ScanFn), exp []scanMatch) {
+			var out []scanMatch
+			scan(text, func(start, end int, k string, v interface{}) bool {
+				out = append(out, scanMatch{start, end, k})
+				return false
+			})
+			if !reflect.DeepEqual(out, exp) {
+				t.Fatalf("%v %q: mis-match: %v %v", keys, text, out, exp)
+			}
+		}
+		for _, text := range texts {
+			check(text, r.Scan, scan(keys, text))
+		}
+
+		// The scanner is a snapshot of the tree
+		r.DeletePrefix("")
+		for _, text := range texts {
+			check(text, sc.Scan, scan(keys, text))
+			check(text, r.Scan, nil)
+		}
+	}
+}
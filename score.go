@@ -0,0 +1,271 @@
+package radix
+
+import (
+	"container/heap"
+	"math"
+	"strings"
+)
+
+// ScoredEntry is a key and value returned by TopK along
+// with its score
+type ScoredEntry struct {
+	Key   string
+	Value interface{}
+	Score float64
+}
+
+// InsertScored is used to add or update an entry along
+// with its score, see Tree.InsertScored
+func (t *ConcurrentTree) InsertScored(s string, v interface{}, score float64) (interface{}, bool) {
+	t.Lock()
+	defer t.Unlock()
+	return t.Tree.InsertScored(s, v, score)
+}
+
+// InsertScored is like Insert, but also sets the score used to
+// rank the key by TopK. Keys added with Insert have a score of
+// zero, and updating a key with Insert keeps its score. Once this
+// is used, the tree keeps the highest score found below every node
+// up to date, at the cost of refreshing the nodes on the path of
+// every modified key. Trees that never use it store no scores.
+// Returns the previous value and if updated.
+func (t *Tree) InsertScored(s string, v interface{}, score float64) (interface{}, bool) {
+	l, old, updated := t.insert(s, v)
+	t.useScores().setLeaf(l, score)
+	t.rescore(s)
+	return old, updated
+}
+
+// TopK is used to return the highest scored entries
+// under a prefix, see Tree.TopK
+func (t *ConcurrentTree) TopK(prefix string, k int) []ScoredEntry {
+	t.RLock()
+	defer t.RUnlock()
+	return t.Tree.TopK(prefix, k)
+}
+
+// TopK is used to return the k entries under a prefix with the
+// highest scores, from the highest to the lowest, with ties in
+// key order. The subtrees are explored best first using the score
+// summaries of the nodes, so only the branches that can still
+// hold one of the k best entries are visited.
+func (t *Tree) TopK(prefix string, k int) []ScoredEntry {
+	if k <= 0 {
+		return nil
+	}
+	n, path := t.findPrefix(prefix)
+	if n == nil {
+		return nil
+	}
+
+	var out []ScoredEntry
+	h := &scoreHeap{{n: n, key: path, score: t.scores.node(n)}}
+	for h.Len() > 0 && len(out) < k {
+		item := heap.Pop(h).(scoreItem)
+		if item.n == nil {
+			out = append(out, ScoredEntry{Key: item.leaf.key, Value: item.leaf.val, Score: item.score})
+			continue
+		}
+
+		// Expand the node, its leaf and children are bounded
+		// by the summary of the node so they come out later
+		if l := item.n.leaf; l != nil {
+			heap.Push(h, scoreItem{leaf: l, key: l.key, score: t.scores.leaf(l)})
+		}
+		for _, e := range item.n.edges {
+			heap.Push(h, scoreItem{n: e.node, key: item.key + e.node.prefix, score: t.scores.node(e.node)})
+		}
+	}
+	return out
+}
+
+// scoreIndex holds the scores of a tree once InsertScored is used,
+// so the nodes of trees without scores stay small. Missing entries
+// score zero, which is also what every key scores before the index
+// is created.
+type scoreIndex struct {
+	// leaves holds the scores set with InsertScored
+	leaves map[*leafNode]float64
+
+	// max holds the highest score of the leaves under each node
+	max map[*node]float64
+}
+
+// useScores returns the score index of the tree, creating
+// an empty one if needed
+func (t *Tree) useScores() *scoreIndex {
+	if t.scores == nil {
+		t.scores = &scoreIndex{
+			leaves: make(map[*leafNode]float64),
+			max:    make(map[*node]float64),
+		}
+	}
+	return t.scores
+}
+
+// leaf returns the score of l, the index may be nil
+func (s *scoreIndex) leaf(l *leafNode) float64 {
+	if s == nil {
+		return 0
+	}
+	return s.leaves[l]
+}
+
+// node returns the highest score under n, the index may be nil
+func (s *scoreIndex) node(n *node) float64 {
+	if s == nil {
+		return 0
+	}
+	return s.max[n]
+}
+
+// setLeaf is used to set the score of l
+func (s *scoreIndex) setLeaf(l *leafNode, score float64) {
+	if score == 0 {
+		delete(s.leaves, l)
+		return
+	}
+	s.leaves[l] = score
+}
+
+// update is used to recompute the score summary of n
+func (s *scoreIndex) update(n *node) {
+	if max := s.summary(n); max != 0 {
+		s.max[n] = max
+	} else {
+		delete(s.max, n)
+	}
+}
+
+// summary returns the highest score under n, computed from
+// its leaf and the summaries of its children
+func (s *scoreIndex) summary(n *node) float64 {
+	max := math.Inf(-1)
+	if n.leaf != nil {
+		max = s.leaves[n.leaf]
+	}
+	for _, e := range n.edges {
+		if v := s.max[e.node]; v > max {
+			max = v
+		}
+	}
+	return max
+}
+
+// forget is used to drop the entries of a node and its leaf
+// that are no longer part of the tree, the index may be nil
+func (s *scoreIndex) forget(n *node, l *leafNode) {
+	if s == nil {
+		return
+	}
+	if n != nil {
+		delete(s.max, n)
+	}
+	if l != nil {
+		delete(s.leaves, l)
+	}
+}
+
+// copyFrom is used to set the scores of nc, a copy of the
+// subtree under n whose scores are held by from
+func (s *scoreIndex) copyFrom(from *scoreIndex, nc, n *node) {
+	if v, ok := from.max[n]; ok {
+		s.max[nc] = v
+	}
+	if n.leaf != nil {
+		if v, ok := from.leaves[n.leaf]; ok {
+			s.leaves[nc.leaf] = v
+		}
+	}
+	for i, e := range n.edges {
+		s.copyFrom(from, nc.edges[i].node, e.node)
+	}
+}
+
+// moveFrom is used to move the scores of the subtree under
+// n, which was taken out of the tree of from
+func (s *scoreIndex) moveFrom(from *scoreIndex, n *node) {
+	s.copyFrom(from, n, n)
+	from.forgetAll(n)
+}
+
+// forgetAll is used to drop the entries of the subtree under n,
+// the index may be nil
+func (s *scoreIndex) forgetAll(n *node) {
+	if s == nil {
+		return
+	}
+	s.forget(n, n.leaf)
+	for _, e := range n.edges {
+		s.forgetAll(e.node)
+	}
+}
+
+// rescore is used to refresh the score summaries of the nodes
+// on the path to key, from the bottom up, after the tree was
+// modified there. The nodes off the path must be up to date.
+func (t *Tree) rescore(key string) {
+	if t.scores == nil {
+		return
+	}
+	path := []*node{t.root}
+	n := t.root
+	search := key
+	for len(search) > 0 {
+		n = n.getEdge(search[0])
+		if n == nil {
+			break
+		}
+		path = append(path, n)
+		if !strings.HasPrefix(search, n.prefix) {
+			break
+		}
+		search = search[len(n.prefix):]
+	}
+	for i := len(path) - 1; i >= 0; i-- {
+		t.scores.update(path[i])
+	}
+}
+
+// scoreItem is a node or a leaf waiting to be visited by TopK
+type scoreItem struct {
+	n     *node
+	leaf  *leafNode
+	key   string
+	score float64
+}
+
+// scoreHeap orders the items by score, highest first, and
+// then by key. A node sorts under the path to it, which is
+// before every key below it.
+type scoreHeap []scoreItem
+
+func (h scoreHeap) Len() int {
+	return len(h)
+}
+
+func (h scoreHeap) Less(i, j int) bool {
+	if h[i].score != h[j].score {
+		return h[i].score > h[j].score
+	}
+	if h[i].key != h[j].key {
+		return h[i].key < h[j].key
+	}
+	// A node sorts before its own leaf
+	return h[i].n != nil && h[j].n == nil
+}
+
+func (h scoreHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+}
+
+func (h *scoreHeap) Push(x interface{}) {
+	*h = append(*h, x.(scoreItem))
+}
+
+func (h *scoreHeap) Pop() interface{} {
+	old := *h
+	item := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return item
+}
@@ -0,0 +1,309 @@
+package radix
+
+import (
+	"math/rand"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// topK is a plain implementation of TopK over a map of scores
+func topK(scores map[string]float64, prefix string, k int) []ScoredEntry {
+	var out []ScoredEntry
+	for key, score := range scores {
+		if strings.HasPrefix(key, prefix) {
+			out = append(out, ScoredEntry{Key: key, Value: key, Score: score})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Score != out[j].Score {
+			return out[i].Score > out[j].Score
+		}
+		return out[i].Key < out[j].Key
+	})
+	if len(out) > k {
+		out = out[:k]
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+func TestTopK(t *testing.T) {
+	r := NewConcurrentTree()
+	completions := map[string]float64{
+		"go":         5,
+		"golang":     9,
+		"google":     10,
+		"gopher":     7,
+		"gorilla":    1,
+		"graphql":    8,
+		"grpc":       8,
+		"python":     6,
+		"postgresql": 4,
+	}
+	for k, score := range completions {
+		r.InsertScored(k, k, score)
+	}
+
+	cases := []struct {
+		prefix string
+		k      int
+	}{
+		{"", 3},
+		{"g", 4},
+		{"go", 10},
+		{"gr", 1},
+		{"p", 2},
+		{"x", 3},
+		{"g", 0},
+	}
+	for _, c := range cases {
+		out := r.TopK(c.prefix, c.k)
+		exp := topK(completions, c.prefix, c.k)
+		if !reflect.DeepEqual(out, exp) {
+			t.Fatalf("%q %d: mis-match: %v %v", c.prefix, c.k, out, exp)
+		}
+	}
+
+	// Updating with Insert keeps the score
+	if _, ok := r.Insert("gorilla", "ape"); !ok {
+		t.Fatalf("expected update")
+	}
+	r.InsertScored("gorilla", "gorilla", 20)
+	completions["gorilla"] = 20
+	r.Insert("gorilla", "gorilla")
+	if out := r.TopK("go", 1); out[0].Key != "gorilla" || out[0].Score != 20 {
+		t.Fatalf("bad top entry: %v", out)
+	}
+
+	// Keys inserted without a score rank at zero
+	r.Insert("gox", "gox")
+	completions["gox"] = 0
+	if out, exp := r.TopK("go", 10), topK(completions, "go", 10); !reflect.DeepEqual(out, exp) {
+		t.Fatalf("mis-match: %v %v", out, exp)
+	}
+	if err := r.Validate(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+}
+
+func TestTopKMutations(t *testing.T) {
+	rng := rand.New(rand.NewSource(42))
+	key := func() string {
+		b := make([]byte, 1+rng.Intn(5))
+		for i := range b {
+			b[i] = "abc"[rng.Intn(3)]
+		}
+		return string(b)
+	}
+
+	for _, pooled := range []bool{false, true} {
+		r := New()
+		if pooled {
+			r = New(WithNodePool(0))
+		}
+		scores := make(map[string]float64)
+		for i := 0; i < 2000; i++ {
+			k := key()
+			switch op := rng.Intn(14); {
+			case op < 5:
+				score := float64(rng.Intn(100) - 20)
+				r.InsertScored(k, k, score)
+				scores[k] = score
+			case op < 7:
+				r.Delete(k)
+				delete(scores, k)
+			case op < 8:
+				prefix := k[:1+rng.Intn(len(k))]
+				r.DeletePrefix(prefix)
+				for s := range scores {
+					if strings.HasPrefix(s, prefix) {
+						delete(scores, s)
+					}
+				}
+			case op < 9:
+				r.DeleteFunc(k[:1], func(s string, v interface{}) bool {
+					if scores[s] < 0 {
+						delete(scores, s)
+						return true
+					}
+					return false
+				})
+			case op < 10:
+				r = r.Clone()
+			case op < 11:
+				from, to := k[:rng.Intn(len(k)+1)], key()
+				r.RenamePrefix(from, to, true)
+				moved := make(map[string]float64)
+				for s, score := range scores {
+					if strings.HasPrefix(s, from) {
+						moved[to+s[len(from):]] = score
+						delete(scores, s)
+					}
+				}
+				for s, score := range moved {
+					scores[s] = score
+				}
+			case op < 12:
+				prefix := k[:1+rng.Intn(len(k))]
+				popped := r.PopPrefix(prefix)
+				if err := popped.Validate(); err != nil {
+					t.Fatalf("err: %v", err)
+				}
+				for s := range scores {
+					if strings.HasPrefix(s, prefix) {
+						delete(scores, s)
+					}
+				}
+			case op < 13:
+				from, to := k[:1+rng.Intn(len(k))], key()
+				sub := r.SubTree(from, true)
+				r.ReplacePrefix(to, sub)
+				moved := make(map[string]float64)
+				for s, score := range scores {
+					if strings.HasPrefix(s, from) {
+						moved[to+s[len(from):]] = score
+					}
+				}
+				for s := range scores {
+					if strings.HasPrefix(s, to) {
+						delete(scores, s)
+					}
+				}
+				for s, score := range moved {
+					scores[s] = score
+				}
+			default:
+				// Colliding keys keep the score of the tree merged into
+				other := New()
+				added := make(map[string]float64)
+				for j := 0; j < 5; j++ {
+					s := key()
+					score := float64(rng.Intn(100))
+					other.InsertScored(s, s, score)
+					added[s] = score
+				}
+				for s, score := range added {
+					if _, ok := scores[s]; !ok {
+						scores[s] = score
+					}
+				}
+				if op%2 == 0 {
+					r.Merge(other, nil)
+				} else {
+					r = Union(r, other, nil)
+				}
+			}
+
+			if err := r.Validate(); err != nil {
+				t.Fatalf("err: %v", err)
+			}
+			prefix := k[:rng.Intn(len(k)+1)]
+			n := rng.Intn(8)
+			out := r.TopK(prefix, n)
+			for i := range out {
+				// Values are not moved along in the model
+				out[i].Value = out[i].Key
+			}
+			if exp := topK(scores, prefix, n); !reflect.DeepEqual(out, exp) {
+				t.Fatalf("pooled %v %q %d: mis-match: %v %v", pooled, prefix, n, out, exp)
+			}
+		}
+	}
+}
+
+func TestTopKDetach(t *testing.T) {
+	// Deleting a scored subtree without a node pool drops its scores
+	r := New()
+	r.InsertScored("a/x", nil, 5)
+	r.InsertScored("a/y", nil, 7)
+	r.DeletePrefix("a/")
+	if err := r.Validate(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	r.InsertScored("a/x", nil, 5)
+	r.DeletePrefixFunc("a/", func(string, interface{}) bool { return false })
+	if err := r.Validate(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Moving the whole tree keeps the summary of its root
+	r = New()
+	r.InsertScored("a", 1, 5)
+	r.InsertScored("b", 2, 9)
+	r.RenamePrefix("", "x/", false)
+	r.InsertScored("c", 3, 3)
+	if err := r.Validate(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out, exp := r.TopK("", 1), []ScoredEntry{{"x/b", 2, 9}}; !reflect.DeepEqual(out, exp) {
+		t.Fatalf("mis-match: %v %v", out, exp)
+	}
+}
+
+func TestTopKSubtrees(t *testing.T) {
+	r := New()
+	for i, k := range []string{"a/x", "a/y", "a/z", "b/x", "b/y"} {
+		r.InsertScored(k, k, float64(i))
+	}
+
+	sub := r.SubTree("a/", true)
+	exp := []ScoredEntry{{"z", "a/z", 2}, {"y", "a/y", 1}}
+	if out := sub.TopK("", 2); !reflect.DeepEqual(out, exp) {
+		t.Fatalf("mis-match: %v %v", out, exp)
+	}
+
+	if _, err := r.RenamePrefix("a/", "c/", false); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	exp = []ScoredEntry{{"b/y", "b/y", 4}, {"b/x", "b/x", 3}, {"c/z", "a/z", 2}}
+	if out := r.TopK("", 3); !reflect.DeepEqual(out, exp) {
+		t.Fatalf("mis-match: %v %v", out, exp)
+	}
+
+	popped := r.PopPrefix("b/")
+	exp = []ScoredEntry{{"b/y", "b/y", 4}}
+	if out := popped.TopK("b", 1); !reflect.DeepEqual(out, exp) {
+		t.Fatalf("mis-match: %v %v", out, exp)
+	}
+	exp = []ScoredEntry{{"c/z", "a/z", 2}}
+	if out := r.TopK("", 1); !reflect.DeepEqual(out, exp) {
+		t.Fatalf("mis-match: %v %v", out, exp)
+	}
+
+	r.ReplacePrefix("d/", popped)
+	exp = []ScoredEntry{{"d/b/y", "b/y", 4}, {"d/b/x", "b/x", 3}}
+	if out := r.TopK("", 2); !reflect.DeepEqual(out, exp) {
+		t.Fatalf("mis-match: %v %v", out, exp)
+	}
+	for _, tree := range []*Tree{r, sub, popped} {
+		if err := tree.Validate(); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+}
+
+func TestTopKUnscored(t *testing.T) {
+	// Trees without scores never create a score index
+	r := New()
+	for _, k := range []string{"b", "a", "c"} {
+		r.Insert(k, k)
+	}
+	u := Union(r, r.SubTree("a", false), nil)
+	u.Merge(r.Clone(), nil)
+	u.RenamePrefix("a", "d", true)
+	for _, tree := range []*Tree{r, u, u.PopPrefix("b")} {
+		if tree.scores != nil {
+			t.Fatalf("unexpected score index")
+		}
+	}
+
+	// Unscored keys rank at zero in key order
+	exp := []ScoredEntry{{"c", "c", 0}, {"d", "a", 0}}
+	if out := u.TopK("", 3); !reflect.DeepEqual(out, exp) {
+		t.Fatalf("mis-match: %v %v", out, exp)
+	}
+}
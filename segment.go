@@ -0,0 +1,73 @@
+package radix
+
+// WithSegmentDelimiter makes the prefix matching methods only
+// accept matches on segment boundaries with delim. LongestPrefix,
+// ShortestPrefix, AllPrefixes, PrefixIterator and WalkPath only
+// return the keys ending on a segment boundary of the string, like
+// LongestPrefixSegment. WalkPrefix and WalkPrefixGet only match the
+// keys where the prefix ends on a segment boundary, like
+// WalkPrefixSegment. Every other method, including DeletePrefix,
+// matches plain byte prefixes. The option is carried over to the trees returned by
+// Clone, SubTree, PopPrefix and the set operations.
+func WithSegmentDelimiter(delim byte) Option {
+	return func(t *Tree) {
+		t.segmented = true
+		t.delim = delim
+	}
+}
+
+// segmentBoundary checks if position i of s is at the start or
+// end of s, or right before or after delim
+func segmentBoundary(s string, i int, delim byte) bool {
+	return i == 0 || i == len(s) || s[i] == delim || s[i-1] == delim
+}
+
+// LongestPrefixSegment is like LongestPrefix, but only accepts
+// matches ending on a segment boundary
+func (t *ConcurrentTree) LongestPrefixSegment(s string, delim byte) (string, interface{}, bool) {
+	t.RLock()
+	defer t.RUnlock()
+	return t.Tree.LongestPrefixSegment(s, delim)
+}
+
+// LongestPrefixSegment is like LongestPrefix, but only accepts
+// matches ending on a segment boundary of s, which is the end of
+// s or right before or after delim. With a delim of '/' the key
+// "/api/user" does not match "/api/users", while "/api" and
+// "/api/" both match "/api/users".
+func (t *Tree) LongestPrefixSegment(s string, delim byte) (string, interface{}, bool) {
+	var key string
+	var val interface{}
+	found := false
+	it := PrefixIterator{n: t.root, search: s, s: s, segmented: true, delim: delim}
+	for k, v, ok := it.Next(); ok; k, v, ok = it.Next() {
+		key, val, found = k, v, true
+	}
+	return key, val, found
+}
+
+// WalkPrefixSegment is like WalkPrefix, but only visits the
+// keys where the prefix ends on a segment boundary
+func (t *ConcurrentTree) WalkPrefixSegment(prefix string, delim byte, fn WalkFn) {
+	t.RLock()
+	defer t.RUnlock()
+	t.Tree.WalkPrefixSegment(prefix, delim, fn)
+}
+
+// WalkPrefixSegment is like WalkPrefix, but only visits the keys
+// where the prefix ends on a segment boundary of the key, which is
+// the end of the key or right before or after delim. With a delim
+// of '/' the prefix "/api/user" visits "/api/user" and "/api/user/1"
+// but not "/api/users".
+func (t *Tree) WalkPrefixSegment(prefix string, delim byte, fn WalkFn) {
+	if len(prefix) == 0 || prefix[len(prefix)-1] == delim {
+		t.walkPrefix(prefix, fn)
+		return
+	}
+
+	// Visit the prefix itself, then everything past the delimiter
+	if v, ok := t.Get(prefix); ok && fn(prefix, v) {
+		return
+	}
+	t.walkPrefix(prefix+string(delim), fn)
+}
@@ -0,0 +1,171 @@
+package radix
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLongestPrefixSegment(t *testing.T) {
+	keys := []string{"", "/api", "/api/", "/api/user", "/api/users/admin", "/static"}
+
+	type exp struct {
+		inp string
+		out string
+	}
+	cases := []exp{
+		{"", ""},
+		{"/", ""},
+		{"/ap", ""},
+		{"/api", "/api"},
+		{"/apiv2", ""},
+		{"/api/", "/api/"},
+		{"/api/users", "/api/"},
+		{"/api/user", "/api/user"},
+		{"/api/user/42", "/api/user"},
+		{"/api/users/admin", "/api/users/admin"},
+		{"/api/users/administrator", "/api/"},
+		{"/staticfiles", ""},
+	}
+
+	r := New()
+	rs := New(WithSegmentDelimiter('/'))
+	for _, k := range keys {
+		r.Insert(k, k)
+		rs.Insert(k, k)
+	}
+	for _, test := range cases {
+		m, v, ok := r.LongestPrefixSegment(test.inp, '/')
+		if !ok || m != test.out || v != test.out {
+			t.Fatalf("mis-match: %v %v", m, test)
+		}
+		if m, _, _ = rs.LongestPrefix(test.inp); m != test.out {
+			t.Fatalf("option mis-match: %v %v", m, test)
+		}
+	}
+
+	// Without the option nothing changes
+	if m, _, _ := r.LongestPrefix("/api/users"); m != "/api/user" {
+		t.Fatalf("bad longest prefix: %v", m)
+	}
+
+	r.Delete("")
+	if _, _, ok := r.LongestPrefixSegment("/apiv2", '/'); ok {
+		t.Fatalf("unexpected match")
+	}
+}
+
+func TestWalkPrefixSegment(t *testing.T) {
+	keys := []string{"/api", "/api/user", "/api/user/1", "/api/user/2", "/api/users", "/api/users/1", "/apiv2"}
+	r := NewConcurrentTree()
+	rs := New(WithSegmentDelimiter('/'))
+	for _, k := range keys {
+		r.Insert(k, nil)
+		rs.Insert(k, nil)
+	}
+
+	type exp struct {
+		inp string
+		out []string
+	}
+	cases := []exp{
+		{"", keys},
+		{"/", keys},
+		{"/ap", []string{}},
+		{"/api", []string{"/api", "/api/user", "/api/user/1", "/api/user/2", "/api/users", "/api/users/1"}},
+		{"/api/", []string{"/api/user", "/api/user/1", "/api/user/2", "/api/users", "/api/users/1"}},
+		{"/api/user", []string{"/api/user", "/api/user/1", "/api/user/2"}},
+		{"/api/user/", []string{"/api/user/1", "/api/user/2"}},
+		{"/api/use", []string{}},
+		{"/api/users/1", []string{"/api/users/1"}},
+	}
+	for _, test := range cases {
+		out := []string{}
+		fn := func(s string, v interface{}) bool {
+			out = append(out, s)
+			return false
+		}
+		r.WalkPrefixSegment(test.inp, '/', fn)
+		if !reflect.DeepEqual(out, test.out) {
+			t.Fatalf("mis-match: %q %v %v", test.inp, out, test.out)
+		}
+
+		out = []string{}
+		rs.WalkPrefix(test.inp, fn)
+		if !reflect.DeepEqual(out, test.out) {
+			t.Fatalf("option mis-match: %q %v %v", test.inp, out, test.out)
+		}
+	}
+}
+
+func TestSegmentDelimiterOption(t *testing.T) {
+	keys := []string{"/api", "/api/user", "/api/user/1", "/api/users"}
+	build := func() *Tree {
+		r := New(WithSegmentDelimiter('/'), WithNodePool(8))
+		for _, k := range keys {
+			r.Insert(k, k)
+		}
+		return r
+	}
+	r := build()
+
+	if k, _, ok := r.ShortestPrefix("/api/users"); !ok || k != "/api" {
+		t.Fatalf("bad shortest prefix: %q %v", k, ok)
+	}
+	if _, _, ok := r.ShortestPrefix("/apis"); ok {
+		t.Fatalf("unexpected match")
+	}
+	out := []string{}
+	for _, e := range r.AllPrefixes("/api/users") {
+		out = append(out, e.Key)
+	}
+	if exp := []string{"/api", "/api/users"}; !reflect.DeepEqual(out, exp) {
+		t.Fatalf("mis-match: %v %v", out, exp)
+	}
+
+	out = []string{}
+	r.WalkPath("/api/users/1", func(k string, v interface{}) bool {
+		out = append(out, k)
+		return false
+	})
+	if exp := []string{"/api", "/api/users"}; !reflect.DeepEqual(out, exp) {
+		t.Fatalf("mis-match: %v %v", out, exp)
+	}
+
+	var vals []interface{}
+	r.WalkPrefixGet("/api/user", &vals)
+	if exp := []interface{}{"/api/user", "/api/user/1"}; !reflect.DeepEqual(vals, exp) {
+		t.Fatalf("mis-match: %v %v", vals, exp)
+	}
+
+	// DeletePrefix still matches byte prefixes
+	if n := r.DeletePrefix("/api/user"); n != 3 {
+		t.Fatalf("bad delete: %v", n)
+	}
+	if exp := map[string]interface{}{"/api": "/api"}; !reflect.DeepEqual(r.ToMap(), exp) {
+		t.Fatalf("mis-match: %v %v", r.ToMap(), exp)
+	}
+	if err := r.Validate(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Every new tree keeps the options
+	r = build()
+	trees := map[string]*Tree{
+		"clone":     r.Clone(),
+		"subtree":   r.SubTree("/api", false),
+		"union":     Union(r, New(), nil),
+		"intersect": Intersect(r, r, nil),
+		"pop":       r.PopPrefix("/api"),
+	}
+	for name, tree := range trees {
+		if !tree.segmented || tree.delim != '/' || tree.pool == nil || tree.pool.max != 8 {
+			t.Fatalf("%s: options not carried over", name)
+		}
+		if k, _, ok := tree.LongestPrefix("/api/users2"); !ok || k != "/api" {
+			t.Fatalf("%s: bad match %q %v", name, k, ok)
+		}
+	}
+	if tree := Difference(r, New()); !tree.segmented {
+		t.Fatalf("difference: options not carried over")
+	}
+}
@@ -0,0 +1,261 @@
+package radix
+
+// ConflictFn is used to pick the value for a key present in
+// two trees, x is the value from the first tree and y the
+// value from the second.
+type ConflictFn func(k string, x, y interface{}) interface{}
+
+// setOp describes how the nodes of two trees are merged
+type setOp struct {
+	// t is the tree the merged nodes are created for, and a
+	// and b the trees holding the scores of both sides
+	t    *Tree
+	a, b *Tree
+
+	// keepA and keepB are set to keep the keys found on
+	// only one side
+	keepA, keepB bool
+
+	// resolve picks the value for a key found on both sides,
+	// returning false to drop the key
+	resolve func(la, lb *leafNode) (interface{}, bool)
+
+	// inPlace is set if the nodes of the first tree can be
+	// modified and reused, it is the tree being merged into
+	inPlace bool
+
+	// reuseB is set if the nodes of the second tree can be moved
+	// into the first, the leaves of the second tree then replace
+	// the colliding ones without calling resolve
+	reuseB bool
+
+	// added counts the leaves copied into t, and collided the
+	// keys found on both sides
+	added    int
+	collided int
+}
+
+// setChild is a subtree along with its remaining prefix, which
+// may be a suffix of the prefix of its node
+type setChild struct {
+	n      *node
+	prefix string
+}
+
+// children splits the subtree n with the remaining prefix p at
+// offset c, returning its leaf and its children if the prefix
+// ends there, or the rest of the prefix as a single child
+func children(n *node, p string, c int) (*leafNode, []setChild) {
+	if c < len(p) {
+		return nil, []setChild{{n, p[c:]}}
+	}
+	out := make([]setChild, len(n.edges))
+	for i, e := range n.edges {
+		out[i] = setChild{e.node, e.node.prefix}
+	}
+	return n.leaf, out
+}
+
+// combine is used to merge the subtrees a and b, whose remaining
+// prefixes pa and pb start at the same position of the key space.
+// The prefixes are split where they diverge and the sorted children
+// of both sides are merged, recursing only where both sides have a
+// child with the same label. Returns the merged subtree, or nil if
+// it is empty. The root is never merged away.
+func (op *setOp) combine(a *node, pa string, b *node, pb string, root bool) *node {
+	c := longestPrefix(pa, pb)
+	la, ca := children(a, pa, c)
+	lb, cb := children(b, pb, c)
+	if op.reuseB && c == len(pb) {
+		// The children of b are moved over and b is dropped
+		op.t.freeNode(b)
+	}
+
+	var n *node
+	if op.inPlace && c == len(pa) {
+		n = a
+	} else {
+		n = op.t.newNode()
+	}
+	n.prefix = pa[:c]
+	n.leaf = op.leaf(la, lb)
+
+	var out edges
+	i, j := 0, 0
+	for i < len(ca) || j < len(cb) {
+		var child *node
+		switch {
+		case j == len(cb) || (i < len(ca) && ca[i].prefix[0] < cb[j].prefix[0]):
+			child = op.only(ca[i], op.keepA, op.inPlace, op.a)
+			i++
+		case i == len(ca) || cb[j].prefix[0] < ca[i].prefix[0]:
+			child = op.only(cb[j], op.keepB, op.reuseB, op.b)
+			j++
+		default:
+			child = op.combine(ca[i].n, ca[i].prefix, cb[j].n, cb[j].prefix, false)
+			i++
+			j++
+		}
+		if child != nil {
+			out = append(out, edge{label: child.prefix[0], node: child})
+		}
+	}
+	n.edges = out
+
+	if !root && n.leaf == nil {
+		switch len(n.edges) {
+		case 0:
+			op.t.freeNode(n)
+			return nil
+		case 1:
+			op.t.freeNode(n.mergeChild())
+		}
+	}
+	if op.t.scores != nil {
+		op.t.scores.update(n)
+	}
+	return n
+}
+
+// only is used to handle a subtree found on one side, which is
+// dropped unless keep is set, and reused as is if reuse is set.
+// Otherwise it is copied along with its scores held by src.
+func (op *setOp) only(c setChild, keep, reuse bool, src *Tree) *node {
+	if !keep {
+		return nil
+	}
+	if reuse {
+		c.n.prefix = c.prefix
+		return c.n
+	}
+	n, num := cloneNode(c.n, nil, nil)
+	n.prefix = c.prefix
+	op.added += num
+	if src.scores != nil {
+		op.t.scores.copyFrom(src.scores, n, c.n)
+	}
+	return n
+}
+
+// leaf is used to pick the leaf for a position given the leaves
+// of both sides, either of which may be nil
+func (op *setOp) leaf(la, lb *leafNode) *leafNode {
+	switch {
+	case la != nil && lb != nil:
+		op.collided++
+		if op.reuseB {
+			op.t.freeLeaf(la)
+			return lb
+		}
+		v, ok := op.resolve(la, lb)
+		if !ok {
+			return nil
+		}
+		if op.inPlace {
+			la.val = v
+			return la
+		}
+		return op.newLeaf(la, v, op.a)
+	case la != nil && op.keepA:
+		if op.inPlace {
+			return la
+		}
+		return op.newLeaf(la, la.val, op.a)
+	case lb != nil && op.keepB:
+		if op.reuseB {
+			return lb
+		}
+		return op.newLeaf(lb, lb.val, op.b)
+	}
+	return nil
+}
+
+// newLeaf returns a copy of l with the value v, keeping
+// its score held by src
+func (op *setOp) newLeaf(l *leafNode, v interface{}, src *Tree) *leafNode {
+	op.added++
+	out := op.t.newLeaf(l.key, v)
+	if src.scores != nil {
+		op.t.scores.setLeaf(out, src.scores.leaf(l))
+	}
+	return out
+}
+
+// build is used to return a new tree with the merged nodes
+// of a and b, with the same options as a
+func (op *setOp) build(a, b *Tree) *Tree {
+	op.t, op.a, op.b = a.emptyLike(), a, b
+	if a.scores != nil || b.scores != nil {
+		op.t.useScores()
+	}
+	op.t.root = op.combine(a.root, "", b.root, "", true)
+	op.t.size = op.added
+	return op.t
+}
+
+// Union returns a new tree with the keys of both a and b. Keys
+// present in both trees get the value returned by resolve, or
+// the value from b if resolve is nil. The nodes of both trees
+// are merged directly, so subtrees found in only one of them
+// are copied as a whole. The result has the options of a.
+func Union(a, b *Tree, resolve ConflictFn) *Tree {
+	op := &setOp{keepA: true, keepB: true, resolve: resolveConflict(resolve)}
+	return op.build(a, b)
+}
+
+// Intersect returns a new tree with the keys present in both a
+// and b. Values are picked by resolve, or taken from b if resolve
+// is nil. Subtrees found in only one of the trees are skipped
+// without being visited. The result has the options of a.
+func Intersect(a, b *Tree, resolve ConflictFn) *Tree {
+	op := &setOp{resolve: resolveConflict(resolve)}
+	return op.build(a, b)
+}
+
+// Difference returns a new tree with the keys of a that are
+// not present in b. Subtrees found only in b are skipped without
+// being visited. The result has the options of a.
+func Difference(a, b *Tree) *Tree {
+	op := &setOp{keepA: true, resolve: func(la, lb *leafNode) (interface{}, bool) {
+		return nil, false
+	}}
+	return op.build(a, b)
+}
+
+// resolveConflict returns the resolve function of a setOp
+// for a ConflictFn, which may be nil
+func resolveConflict(resolve ConflictFn) func(la, lb *leafNode) (interface{}, bool) {
+	return func(la, lb *leafNode) (interface{}, bool) {
+		if resolve == nil {
+			return lb.val, true
+		}
+		return resolve(la.key, la.val, lb.val), true
+	}
+}
+
+// Merge is used to insert every entry of other into the tree.
+// The caller must make sure other is not modified concurrently.
+func (t *ConcurrentTree) Merge(other *Tree, conflict ConflictFn) {
+	t.Lock()
+	defer t.Unlock()
+	t.Tree.Merge(other, conflict)
+}
+
+// Merge is used to insert every entry of other into the tree.
+// Keys already present get the value returned by conflict,
+// called with the existing value first, or the value from
+// other if conflict is nil. The nodes of other are merged into
+// the tree in a single pass, subtrees only found in other are
+// copied as a whole and subtrees only found in the tree are
+// left untouched. other is not modified.
+func (t *Tree) Merge(other *Tree, conflict ConflictFn) {
+	if other == t {
+		other = t.Clone()
+	}
+	op := &setOp{t: t, a: t, b: other, keepA: true, keepB: true, inPlace: true, resolve: resolveConflict(conflict)}
+	if other.scores != nil {
+		t.useScores()
+	}
+	t.root = op.combine(t.root, "", other.root, "", true)
+	t.size += op.added
+}
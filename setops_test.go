@@ -0,0 +1,175 @@
+package radix
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestSetOperations(t *testing.T) {
+	a := New()
+	for _, k := range []string{"", "foo", "foobar", "zip"} {
+		a.Insert(k, "a")
+	}
+	b := New()
+	for _, k := range []string{"foo", "foobaz", "zip", "zipzap"} {
+		b.Insert(k, "b")
+	}
+	concat := func(k string, x, y interface{}) interface{} {
+		return x.(string) + y.(string)
+	}
+
+	type exp struct {
+		name string
+		out  *Tree
+		exp  map[string]interface{}
+	}
+	cases := []exp{
+		{
+			"union",
+			Union(a, b, nil),
+			map[string]interface{}{"": "a", "foo": "b", "foobar": "a", "foobaz": "b", "zip": "b", "zipzap": "b"},
+		},
+		{
+			"union resolve",
+			Union(a, b, concat),
+			map[string]interface{}{"": "a", "foo": "ab", "foobar": "a", "foobaz": "b", "zip": "ab", "zipzap": "b"},
+		},
+		{
+			"intersect",
+			Intersect(a, b, nil),
+			map[string]interface{}{"foo": "b", "zip": "b"},
+		},
+		{
+			"intersect resolve",
+			Intersect(a, b, concat),
+			map[string]interface{}{"foo": "ab", "zip": "ab"},
+		},
+		{
+			"difference",
+			Difference(a, b),
+			map[string]interface{}{"": "a", "foobar": "a"},
+		},
+		{
+			"difference reversed",
+			Difference(b, a),
+			map[string]interface{}{"foobaz": "b", "zipzap": "b"},
+		},
+		{
+			"difference self",
+			Difference(a, a),
+			map[string]interface{}{},
+		},
+	}
+	for _, test := range cases {
+		if err := test.out.Validate(); err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if out := test.out.ToMap(); !reflect.DeepEqual(out, test.exp) {
+			t.Fatalf("%s: mis-match: %v %v", test.name, out, test.exp)
+		}
+	}
+}
+
+func TestMerge(t *testing.T) {
+	r := NewConcurrentTree()
+	r.Insert("foo", 1)
+	r.Insert("zip", 2)
+
+	other := New()
+	other.Insert("foo", 10)
+	other.Insert("foobar", 20)
+
+	r.Merge(other, func(k string, x, y interface{}) interface{} {
+		return x.(int) + y.(int)
+	})
+	exp := map[string]interface{}{"foo": 11, "foobar": 20, "zip": 2}
+	if out := r.ToMap(); !reflect.DeepEqual(out, exp) {
+		t.Fatalf("mis-match: %v %v", out, exp)
+	}
+
+	r.Merge(other, nil)
+	exp["foo"] = 10
+	if out := r.ToMap(); !reflect.DeepEqual(out, exp) {
+		t.Fatalf("mis-match: %v %v", out, exp)
+	}
+	if other.Len() != 2 {
+		t.Fatalf("other was modified: %v", other.ToMap())
+	}
+}
+
+func TestSetOperationsModel(t *testing.T) {
+	rng := rand.New(rand.NewSource(3))
+	random := func() (*Tree, map[string]interface{}) {
+		r := New()
+		m := make(map[string]interface{})
+		for i := 0; i < rng.Intn(40); i++ {
+			b := make([]byte, rng.Intn(6))
+			for j := range b {
+				b[j] = "abc"[rng.Intn(3)]
+			}
+			r.Insert(string(b), i)
+			m[string(b)] = i
+		}
+		return r, m
+	}
+	sum := func(k string, x, y interface{}) interface{} {
+		return x.(int)*100 + y.(int)
+	}
+
+	for i := 0; i < 200; i++ {
+		a, ma := random()
+		b, mb := random()
+
+		union := make(map[string]interface{})
+		inter := make(map[string]interface{})
+		diff := make(map[string]interface{})
+		for k, v := range ma {
+			union[k] = v
+			diff[k] = v
+		}
+		for k, v := range mb {
+			if x, ok := ma[k]; ok {
+				union[k] = sum(k, x, v)
+				inter[k] = sum(k, x, v)
+				delete(diff, k)
+			} else {
+				union[k] = v
+			}
+		}
+
+		merged := a.Clone()
+		merged.Merge(b, sum)
+		cases := []struct {
+			name string
+			out  *Tree
+			exp  map[string]interface{}
+		}{
+			{"union", Union(a, b, sum), union},
+			{"intersect", Intersect(a, b, sum), inter},
+			{"difference", Difference(a, b), diff},
+			{"merge", merged, union},
+		}
+		for _, c := range cases {
+			if err := c.out.Validate(); err != nil {
+				t.Fatalf("%s: %v", c.name, err)
+			}
+			if out := c.out.ToMap(); !reflect.DeepEqual(out, c.exp) {
+				t.Fatalf("%s: mis-match: %v %v", c.name, out, c.exp)
+			}
+		}
+
+		// The inputs are left untouched
+		for _, c := range []struct {
+			r *Tree
+			m map[string]interface{}
+		}{{a, ma}, {b, mb}} {
+			if err := c.r.Validate(); err != nil {
+				t.Fatalf("err: %v", err)
+			}
+			if out := c.r.ToMap(); !reflect.DeepEqual(out, c.m) {
+				t.Fatalf("input modified: %v %v", out, c.m)
+			}
+		}
+	}
+}
@@ -0,0 +1,212 @@
+package radix
+
+import (
+	"strings"
+)
+
+// findPrefix is used to find the topmost node holding every key
+// under prefix. Returns the node and its full path, which may be
+// longer than prefix, or nil if no key is under prefix.
+func (t *Tree) findPrefix(prefix string) (*node, string) {
+	_, n, path := t.seekPrefix(prefix)
+	return n, path
+}
+
+// seekPrefix is like findPrefix, but also returns the parent of
+// the node, which is nil for the root
+func (t *Tree) seekPrefix(prefix string) (*node, *node, string) {
+	var parent *node
+	n := t.root
+	search := prefix
+	path := ""
+	for {
+		// Check for key exhaution
+		if len(search) == 0 {
+			return parent, n, path
+		}
+
+		// Look for an edge
+		parent = n
+		n = n.getEdge(search[0])
+		if n == nil {
+			return nil, nil, ""
+		}
+		path += n.prefix
+
+		// Consume the search prefix
+		if strings.HasPrefix(search, n.prefix) {
+			search = search[len(n.prefix):]
+		} else if strings.HasPrefix(n.prefix, search) {
+			// Child may be under our search prefix
+			return parent, n, path
+		} else {
+			return nil, nil, ""
+		}
+	}
+}
+
+// SubTree is used to copy every entry under a prefix into a new
+// tree. If strip is set the prefix is removed from the keys.
+func (t *ConcurrentTree) SubTree(prefix string, strip bool) *Tree {
+	t.RLock()
+	defer t.RUnlock()
+	return t.Tree.SubTree(prefix, strip)
+}
+
+// SubTree is used to copy every entry under a prefix into a new
+// tree. If strip is set the prefix is removed from the keys, so
+// the entry "tenant/42/name" becomes "name" for the prefix
+// "tenant/42/". The nodes are copied directly, values are shared.
+func (t *Tree) SubTree(prefix string, strip bool) *Tree {
+	out := t.emptyLike()
+	n, path := t.findPrefix(prefix)
+	if n == nil {
+		return out
+	}
+
+	var key func(k string) string
+	if strip {
+		key = func(k string) string {
+			return k[len(prefix):]
+		}
+		path = path[len(prefix):]
+	}
+	sub, size := cloneNode(n, nil, key)
+	out.size = size
+	if t.scores != nil {
+		out.useScores().copyFrom(t.scores, sub, n)
+	}
+	if path == "" {
+		sub.prefix = ""
+		out.root = sub
+	} else {
+		sub.prefix = path
+		out.root.edges = edges{{label: path[0], node: sub}}
+	}
+	out.rescore("")
+	return out
+}
+
+// ReplacePrefix is used to atomically replace every entry under
+// a prefix with the entries of sub, see Tree.ReplacePrefix
+func (t *ConcurrentTree) ReplacePrefix(prefix string, sub *Tree) int {
+	t.Lock()
+	defer t.Unlock()
+	return t.Tree.ReplacePrefix(prefix, sub)
+}
+
+// ReplacePrefix is used to replace every entry under a prefix with
+// the entries of sub, which are stored with the prefix prepended to
+// their keys. It is the inverse of SubTree with strip set. The nodes
+// of sub are copied and grafted in place instead of being inserted
+// one by one. Returns how many entries were removed.
+func (t *Tree) ReplacePrefix(prefix string, sub *Tree) int {
+	// Copy first in case sub is the tree itself
+	n, size := cloneNode(sub.root, nil, func(k string) string {
+		return prefix + k
+	})
+	if sub.scores != nil {
+		t.useScores().copyFrom(sub.scores, n, sub.root)
+	}
+	removed := t.DeletePrefix(prefix)
+	if size == 0 {
+		t.scores.forgetAll(n)
+		return removed
+	}
+	t.graft(n, prefix, size)
+	return removed
+}
+
+// graft is used to attach the subtree under n at path. The leaf
+// keys under n must already include path, size is the number of
+// leaves under n and nothing may be stored under path yet.
+func (t *Tree) graft(n *node, path string, size int) {
+	if path == "" {
+		n.prefix = ""
+		t.freeNode(t.root)
+		t.root = n
+		t.size = size
+		t.rescore("")
+		return
+	}
+
+	// Nothing is stored under the path, so inserting it creates
+	// a node without children at exactly the path
+	t.Insert(path, nil)
+	target, _ := t.findPrefix(path)
+	t.freeLeaf(target.leaf)
+	target.leaf = n.leaf
+	target.edges = n.edges
+	t.scores.forget(n, nil)
+	t.size += size - 1
+
+	// Keep the node compressed
+	if target.leaf == nil && len(target.edges) == 1 {
+		t.freeNode(target.mergeChild())
+	}
+	t.rescore(path)
+}
+
+// detachPrefix is used to remove the topmost node holding every
+// key under prefix from the tree, returning it along with its full
+// path. The size of the tree is not updated.
+func (t *Tree) detachPrefix(prefix string) (*node, string) {
+	parent, n, path := t.seekPrefix(prefix)
+	if n == nil {
+		return nil, ""
+	}
+
+	if parent == nil {
+		// Detach everything, leaving an empty root behind
+		if n.leaf == nil && len(n.edges) == 0 {
+			return nil, ""
+		}
+		if n.leaf == nil && len(n.edges) == 1 {
+			// Detach the only child, so the subtree stays
+			// compressed wherever it is placed
+			child := n.edges[0].node
+			n.edges = nil
+			t.rescore("")
+			return child, child.prefix
+		}
+		d := &node{leaf: n.leaf, edges: n.edges}
+		if t.scores != nil {
+			t.scores.update(d)
+		}
+		n.leaf = nil
+		n.edges = nil
+		t.rescore("")
+		return d, ""
+	}
+
+	parent.delEdge(n.prefix[0])
+
+	// Check if we should merge the parent's other child
+	if parent != t.root && len(parent.edges) == 1 && !parent.isLeaf() {
+		t.freeNode(parent.mergeChild())
+	}
+	t.rescore(prefix)
+	return n, path
+}
+
+// attach is used to add the subtree under n to the tree at path,
+// moving its nodes into the tree. The leaf keys under n must already
+// include path. The subtree is merged with the entries under path
+// in a single pass, entries with the same key are replaced by the
+// ones under n. Returns how many entries were replaced, the size of
+// the tree is not updated.
+func (t *Tree) attach(n *node, path string) int {
+	op := &setOp{t: t, a: t, b: t, keepA: true, keepB: true, inPlace: true, reuseB: true}
+	t.root = op.combine(t.root, "", placeAt(n, path), "", true)
+	return op.collided
+}
+
+// placeAt returns a root for a tree holding only the subtree under
+// n at path, setting the prefix of n accordingly
+func placeAt(n *node, path string) *node {
+	n.prefix = path
+	if path == "" {
+		return n
+	}
+	return &node{edges: edges{{label: path[0], node: n}}}
+}
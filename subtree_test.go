@@ -0,0 +1,137 @@
+package radix
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSubTree(t *testing.T) {
+	r := New()
+	keys := []string{"", "tenant/1/a", "tenant/42/", "tenant/42/name", "tenant/42/zone", "tenant/420", "zip"}
+	for _, k := range keys {
+		r.Insert(k, k)
+	}
+
+	type exp struct {
+		prefix string
+		strip  bool
+		out    map[string]interface{}
+	}
+	cases := []exp{
+		{"tenant/42/", false, map[string]interface{}{
+			"tenant/42/": "tenant/42/", "tenant/42/name": "tenant/42/name", "tenant/42/zone": "tenant/42/zone",
+		}},
+		{"tenant/42/", true, map[string]interface{}{
+			"": "tenant/42/", "name": "tenant/42/name", "zone": "tenant/42/zone",
+		}},
+		{"tenant/4", true, map[string]interface{}{
+			"2/": "tenant/42/", "2/name": "tenant/42/name", "2/zone": "tenant/42/zone", "20": "tenant/420",
+		}},
+		{"tenant/42/n", true, map[string]interface{}{
+			"ame": "tenant/42/name",
+		}},
+		{"tenant/42/n", false, map[string]interface{}{
+			"tenant/42/name": "tenant/42/name",
+		}},
+		{"", false, r.ToMap()},
+		{"nope", true, map[string]interface{}{}},
+	}
+	for _, test := range cases {
+		sub := r.SubTree(test.prefix, test.strip)
+		if err := sub.Validate(); err != nil {
+			t.Fatalf("%q: %v", test.prefix, err)
+		}
+		if out := sub.ToMap(); !reflect.DeepEqual(out, test.out) {
+			t.Fatalf("%q: mis-match: %v %v", test.prefix, out, test.out)
+		}
+	}
+
+	// The copy must be independent
+	sub := r.SubTree("tenant/", true)
+	sub.Insert("42/new", nil)
+	if _, ok := r.Get("tenant/42/new"); ok {
+		t.Fatalf("original was modified")
+	}
+}
+
+func TestReplacePrefix(t *testing.T) {
+	r := NewConcurrentTree()
+	for _, k := range []string{"tenant/1/a", "tenant/42/name", "tenant/42/zone", "tenant/420", "zip"} {
+		r.Insert(k, 1)
+	}
+
+	sub := New()
+	sub.Insert("", 2)
+	sub.Insert("name", 2)
+	sub.Insert("owner", 2)
+	if n := r.ReplacePrefix("tenant/42/", sub); n != 2 {
+		t.Fatalf("bad removed count: %v", n)
+	}
+	if err := r.Validate(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	exp := map[string]interface{}{
+		"tenant/1/a": 1, "tenant/42/": 2, "tenant/42/name": 2, "tenant/42/owner": 2, "tenant/420": 1, "zip": 1,
+	}
+	if out := r.ToMap(); !reflect.DeepEqual(out, exp) {
+		t.Fatalf("mis-match: %v %v", out, exp)
+	}
+	if r.Len() != len(exp) {
+		t.Fatalf("bad len: %v", r.Len())
+	}
+
+	// A single key subtree must be merged into its parent
+	single := New()
+	single.Insert("x", 3)
+	r.ReplacePrefix("tenant/1/", single)
+	if err := r.Validate(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if v, ok := r.Get("tenant/1/x"); !ok || v != 3 {
+		t.Fatalf("bad value: %v", v)
+	}
+
+	// The segment delimiter does not change the replaced keys
+	seg := New(WithSegmentDelimiter('/'))
+	for _, k := range []string{"t/42", "t/420", "t/42/a"} {
+		seg.Insert(k, 1)
+	}
+	repl := New()
+	repl.Insert("/x", 2)
+	if n := seg.ReplacePrefix("t/42", repl); n != 3 {
+		t.Fatalf("bad removed count: %v", n)
+	}
+	if err := seg.Validate(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out, exp := seg.ToMap(), map[string]interface{}{"t/42/x": 2}; !reflect.DeepEqual(out, exp) || seg.Len() != 1 {
+		t.Fatalf("mis-match: %v %v", out, exp)
+	}
+
+	// Round trip through SubTree
+	tr := New()
+	for k, v := range exp {
+		tr.Insert(k, v)
+	}
+	tr.ReplacePrefix("tenant/", tr.SubTree("tenant/", true))
+	if out := tr.ToMap(); !reflect.DeepEqual(out, exp) {
+		t.Fatalf("mis-match: %v %v", out, exp)
+	}
+
+	// An empty subtree deletes the prefix
+	if n := tr.ReplacePrefix("tenant/", New()); n != 5 {
+		t.Fatalf("bad removed count: %v", n)
+	}
+	if tr.Len() != 1 {
+		t.Fatalf("bad len: %v", tr.Len())
+	}
+
+	// Replacing the root swaps the whole tree
+	tr.ReplacePrefix("", sub)
+	if err := tr.Validate(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out := tr.ToMap(); !reflect.DeepEqual(out, sub.ToMap()) {
+		t.Fatalf("mis-match: %v %v", out, sub.ToMap())
+	}
+}
@@ -0,0 +1,113 @@
+package radix
+
+import (
+	"io"
+	"unicode/utf8"
+)
+
+// tokenizeChunkSize is how many bytes TokenizeReader
+// reads at once
+const tokenizeChunkSize = 4096
+
+// TokenFn is used when tokenizing text with Tokenize. Takes the
+// text of a token, its value and its byte offset in the input. If
+// matched is set the text is a key of the tree, otherwise it is a
+// span of input that no key matched and the value is nil. Returns
+// if tokenizing should be terminated.
+type TokenFn func(text string, v interface{}, offset int, matched bool) bool
+
+// Tokenize is used to split s into the keys of the tree,
+// see Tree.Tokenize
+func (t *ConcurrentTree) Tokenize(s string, fn TokenFn) {
+	t.RLock()
+	defer t.RUnlock()
+	t.Tree.Tokenize(s, fn)
+}
+
+// Tokenize is used to split s into the keys of the tree by maximal
+// munch: starting at the beginning of s, the longest key that is a
+// prefix of the rest of s is taken as the next token, as found by
+// LongestPrefix. When no key matches, the input is skipped one
+// character at a time, and each run of skipped input is reported as
+// a single unmatched span. The empty key never matches. Segment
+// delimiters set with WithSegmentDelimiter are not applied.
+func (t *Tree) Tokenize(s string, fn TokenFn) {
+	t.tokenize(s, 0, true, fn)
+}
+
+// TokenizeReader is used to split the input read from r into
+// the keys of the tree, see Tree.TokenizeReader
+func (t *ConcurrentTree) TokenizeReader(r io.Reader, fn TokenFn) error {
+	t.RLock()
+	defer t.RUnlock()
+	return t.Tree.TokenizeReader(r, fn)
+}
+
+// TokenizeReader is like Tokenize, but reads the input from r until
+// io.EOF. Only the input that may still be part of the next token is
+// buffered, which is at most as long as the longest key. Unmatched
+// spans are reported as they are read, so a run of unmatched input
+// may be split into several spans. Returns any other error from r,
+// once the tokens completed before it are reported.
+func (t *Tree) TokenizeReader(r io.Reader, fn TokenFn) error {
+	var buf []byte
+	chunk := make([]byte, tokenizeChunkSize)
+	offset := 0
+	for {
+		n, err := r.Read(chunk)
+		buf = append(buf, chunk[:n]...)
+		eof := err == io.EOF
+
+		consumed, stop := t.tokenize(string(buf), offset, eof, fn)
+		if stop || eof {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		buf = buf[:copy(buf, buf[consumed:])]
+		offset += consumed
+	}
+}
+
+// tokenize is used to report the tokens of s, which starts at offset
+// in the input. Unless final is set, it stops before the first token
+// that more input could still extend. Returns how many bytes were
+// consumed and if fn asked to stop.
+func (t *Tree) tokenize(s string, offset int, final bool, fn TokenFn) (int, bool) {
+	pos := 0
+	unmatched := -1
+	flush := func() bool {
+		if unmatched < 0 {
+			return false
+		}
+		start := unmatched
+		unmatched = -1
+		return fn(s[start:pos], nil, offset+start, false)
+	}
+
+	for pos < len(s) {
+		leaf, more := t.longestMatch(s[pos:])
+		if more && !final {
+			break
+		}
+		if leaf != nil && leaf.key != "" {
+			if flush() || fn(leaf.key, leaf.val, offset+pos, true) {
+				return pos, true
+			}
+			pos += len(leaf.key)
+			continue
+		}
+
+		// Skip a whole character, which may need more input
+		if !final && !utf8.FullRuneInString(s[pos:]) {
+			break
+		}
+		_, size := utf8.DecodeRuneInString(s[pos:])
+		if unmatched < 0 {
+			unmatched = pos
+		}
+		pos += size
+	}
+	return pos, flush()
+}
@@ -0,0 +1,119 @@
+package radix
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+// token is a single token reported by Tokenize
+type token struct {
+	text    string
+	offset  int
+	matched bool
+}
+
+// collectTokens returns a TokenFn appending to out, merging
+// adjacent unmatched spans
+func collectTokens(out *[]token) TokenFn {
+	return func(text string, v interface{}, offset int, matched bool) bool {
+		if num := len(*out); !matched && num > 0 && !(*out)[num-1].matched {
+			(*out)[num-1].text += text
+			return false
+		}
+		*out = append(*out, token{text, offset, matched})
+		return false
+	}
+}
+
+func TestTokenize(t *testing.T) {
+	r := NewConcurrentTree()
+	for _, k := range []string{"", "=", "==", "===", "if", "iff", "in", "int", "日本"} {
+		r.Insert(k, len(k))
+	}
+
+	cases := []struct {
+		inp string
+		out []token
+	}{
+		{"", nil},
+		{"if", []token{{"if", 0, true}}},
+		{"iffy", []token{{"iff", 0, true}, {"y", 3, false}}},
+		{"int==in", []token{{"int", 0, true}, {"==", 3, true}, {"in", 5, true}}},
+		{"====", []token{{"===", 0, true}, {"=", 3, true}}},
+		{"x if y", []token{{"x ", 0, false}, {"if", 2, true}, {" y", 4, false}}},
+		{"ié日本x", []token{{"ié", 0, false}, {"日本", 3, true}, {"x", 9, false}}},
+	}
+	for _, c := range cases {
+		var out []token
+		r.Tokenize(c.inp, func(text string, v interface{}, offset int, matched bool) bool {
+			if matched && v != len(text) {
+				t.Fatalf("bad value: %q %v", text, v)
+			}
+			out = append(out, token{text, offset, matched})
+			return false
+		})
+		if !reflect.DeepEqual(out, c.out) {
+			t.Fatalf("%q: mis-match: %v %v", c.inp, out, c.out)
+		}
+
+		// Reading one byte at a time gives the same tokens
+		var read []token
+		if err := r.TokenizeReader(iotest.OneByteReader(strings.NewReader(c.inp)), collectTokens(&read)); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if !reflect.DeepEqual(read, c.out) {
+			t.Fatalf("%q: mis-match: %v %v", c.inp, read, c.out)
+		}
+	}
+}
+
+func TestTokenizeStop(t *testing.T) {
+	r := New()
+	r.Insert("a", nil)
+	r.Insert("b", nil)
+
+	var out []token
+	r.Tokenize("ab-ab", func(text string, v interface{}, offset int, matched bool) bool {
+		out = append(out, token{text, offset, matched})
+		return !matched
+	})
+	exp := []token{{"a", 0, true}, {"b", 1, true}, {"-", 2, false}}
+	if !reflect.DeepEqual(out, exp) {
+		t.Fatalf("mis-match: %v %v", out, exp)
+	}
+}
+
+func TestTokenizeReader(t *testing.T) {
+	r := New()
+	for _, k := range []string{"foo", "foobar", "bar"} {
+		r.Insert(k, nil)
+	}
+
+	// Inputs longer than a single read
+	inp := strings.Repeat("foobar.foo", tokenizeChunkSize/7)
+	var exp, out []token
+	r.Tokenize(inp, collectTokens(&exp))
+	if err := r.TokenizeReader(strings.NewReader(inp), collectTokens(&out)); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !reflect.DeepEqual(out, exp) {
+		t.Fatalf("mis-match: %d %d", len(out), len(exp))
+	}
+
+	// Errors are returned after the complete tokens, "foo"
+	// could still become "foobar"
+	errBad := errors.New("bad read")
+	out = nil
+	err := r.TokenizeReader(io.MultiReader(strings.NewReader("bar foo"), iotest.ErrReader(errBad)), collectTokens(&out))
+	if err != errBad {
+		t.Fatalf("err: %v", err)
+	}
+	exp = []token{{"bar", 0, true}, {" ", 3, false}}
+	if !reflect.DeepEqual(out, exp) {
+		t.Fatalf("mis-match: %v %v", out, exp)
+	}
+}
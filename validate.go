@@ -0,0 +1,108 @@
+package radix
+
+import (
+	"fmt"
+)
+
+// Validate is used to check the structural invariants of the tree
+func (t *ConcurrentTree) Validate() error {
+	t.RLock()
+	defer t.RUnlock()
+	return t.Tree.Validate()
+}
+
+// Validate is used to check the structural invariants of the
+// tree, returning an error describing the first violation found.
+// It verifies that edges are sorted and unique, that edge labels
+// match the first byte of the child prefix, that nodes other than
+// the root without a leaf have at least two children, that leaf
+// keys equal the concatenated prefixes on their path, that the
+// score summaries are up to date and only kept for the nodes of
+// the tree, and that the size matches the number of leaves. A
+// valid tree always returns nil, so this is mostly useful in tests
+// and when debugging.
+func (t *Tree) Validate() error {
+	if t.root == nil {
+		return fmt.Errorf("missing root node")
+	}
+	if t.root.prefix != "" {
+		return fmt.Errorf("root has prefix %q", t.root.prefix)
+	}
+	leaves, err := validateNode(t.root, "", true, t.scores)
+	if err != nil {
+		return err
+	}
+	if leaves != t.size {
+		return fmt.Errorf("size is %d but found %d leaves", t.size, leaves)
+	}
+	if t.scores != nil {
+		return t.scores.validate(t.root)
+	}
+	return nil
+}
+
+// validateNode recursively checks n, whose full path from the
+// root is path, and returns the number of leaves below it. The
+// score summaries are only checked if scores is set.
+func validateNode(n *node, path string, root bool, scores *scoreIndex) (int, error) {
+	leaves := 0
+	if n.leaf != nil {
+		if n.leaf.key != path {
+			return 0, fmt.Errorf("leaf key %q does not match path %q", n.leaf.key, path)
+		}
+		leaves++
+	} else if !root && len(n.edges) < 2 {
+		return 0, fmt.Errorf("node %q has no leaf and %d children", path, len(n.edges))
+	}
+
+	for i, e := range n.edges {
+		if i > 0 && n.edges[i-1].label >= e.label {
+			return 0, fmt.Errorf("node %q has unsorted or duplicate edge %q", path, e.label)
+		}
+		if e.node == nil {
+			return 0, fmt.Errorf("node %q has nil child for edge %q", path, e.label)
+		}
+		if len(e.node.prefix) == 0 {
+			return 0, fmt.Errorf("node %q has empty child prefix for edge %q", path, e.label)
+		}
+		if e.node.prefix[0] != e.label {
+			return 0, fmt.Errorf("node %q has edge %q to child with prefix %q", path, e.label, e.node.prefix)
+		}
+		num, err := validateNode(e.node, path+e.node.prefix, false, scores)
+		if err != nil {
+			return 0, err
+		}
+		leaves += num
+	}
+
+	if scores != nil && leaves > 0 {
+		if max := scores.summary(n); scores.node(n) != max {
+			return 0, fmt.Errorf("node %q has max score %v but found %v", path, scores.node(n), max)
+		}
+	}
+	return leaves, nil
+}
+
+// validate is used to check that the index only holds entries
+// for the nodes and leaves under root
+func (s *scoreIndex) validate(root *node) error {
+	nodes, leaves := 0, 0
+	var visit func(n *node)
+	visit = func(n *node) {
+		if _, ok := s.max[n]; ok {
+			nodes++
+		}
+		if _, ok := s.leaves[n.leaf]; ok && n.leaf != nil {
+			leaves++
+		}
+		for _, e := range n.edges {
+			visit(e.node)
+		}
+	}
+	visit(root)
+	if nodes != len(s.max) || leaves != len(s.leaves) {
+		return fmt.Errorf("score index has %d node and %d leaf entries but found %d and %d",
+			len(s.max), len(s.leaves), nodes, leaves)
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package radix
+
+import (
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	r := New()
+	if err := r.Validate(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for _, k := range []string{"", "A", "AB", "ABC", "R", "S"} {
+		r.Insert(k, nil)
+	}
+	if err := r.Validate(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Corrupt the tree in a few ways
+	r.size++
+	if err := r.Validate(); err == nil {
+		t.Fatalf("expected size error")
+	}
+	r.size--
+
+	orig := r.root.edges
+	r.root.edges = edges{orig[1], orig[0], orig[2]}
+	if err := r.Validate(); err == nil {
+		t.Fatalf("expected order error")
+	}
+	r.root.edges = orig
+
+	r.root.edges[0].node.leaf.key = "X"
+	if err := r.Validate(); err == nil {
+		t.Fatalf("expected key error")
+	}
+	r.root.edges[0].node.leaf.key = "A"
+
+	r.root.edges[0].label = 'B'
+	if err := r.Validate(); err == nil {
+		t.Fatalf("expected label error")
+	}
+	r.root.edges[0].label = 'A'
+
+	r.root.edges[0].node.leaf = nil
+	r.size--
+	r.root.edges[0].node.edges[0].node.leaf = nil
+	if err := r.Validate(); err == nil {
+		t.Fatalf("expected missing leaf error")
+	}
+}
+
+func TestValidateDeletePrefix(t *testing.T) {
+	cases := []struct {
+		inp    []string
+		prefix string
+	}{
+		{[]string{"", "A", "AB", "ABC", "R", "S"}, "A"},
+		{[]string{"", "A", "AB", "ABC", "R", "S"}, "AB"},
+		{[]string{"", "A", "AB", "ABC", "R", "S"}, "ABC"},
+		{[]string{"A", "AB", "ABC", "R", "S"}, ""},
+		{[]string{"foo/bar", "foo/baz", "foo/zip"}, "foo/z"},
+		{[]string{"foo/bar", "foo/baz", "foo/zip"}, "foo/ba"},
+		{[]string{"foo/bar", "foo/baz", "zip"}, "foo/bar"},
+	}
+	for _, test := range cases {
+		r := New()
+		for _, k := range test.inp {
+			r.Insert(k, nil)
+		}
+		r.DeletePrefix(test.prefix)
+		if err := r.Validate(); err != nil {
+			t.Fatalf("%v %q: %v", test.inp, test.prefix, err)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package radix
+
+// Cursor is a handle on the current entry of a WalkMut walk. It
+// is only valid until the WalkMutFn it was passed to returns.
+type Cursor struct {
+	leaf    *leafNode
+	deleted bool
+}
+
+// Key returns the key of the current entry
+func (c *Cursor) Key() string {
+	return c.leaf.key
+}
+
+// Value returns the value of the current entry
+func (c *Cursor) Value() interface{} {
+	return c.leaf.val
+}
+
+// Set is used to update the value of the current entry in place.
+// It is ignored if the entry was deleted.
+func (c *Cursor) Set(v interface{}) {
+	if !c.deleted {
+		c.leaf.val = v
+	}
+}
+
+// Delete is used to delete the current entry. The entry is
+// removed from the tree once the walk is complete.
+func (c *Cursor) Delete() {
+	c.deleted = true
+}
+
+// WalkMutFn is used when walking the tree with WalkMut. Takes a
+// cursor on the current entry, returning if iteration should be
+// terminated.
+type WalkMutFn func(c *Cursor) bool
+
+// WalkMut is used to walk the tree under a prefix, allowing the
+// callback to update or delete the current entry through the
+// cursor. The write lock is held for the whole walk, so fn must
+// not call any other method of the tree.
+func (t *ConcurrentTree) WalkMut(prefix string, fn WalkMutFn) {
+	t.Lock()
+	defer t.Unlock()
+	t.Tree.WalkMut(prefix, fn)
+}
+
+// WalkMut is used to walk the tree under a prefix, allowing the
+// callback to update or delete the current entry through the
+// cursor. Updates are applied immediately. Deletions are applied
+// once the walk is complete, in a single pass that keeps the nodes
+// compressed, so every entry under the prefix is visited exactly
+// once no matter what is deleted. fn must only modify the tree
+// through the cursor.
+func (t *Tree) WalkMut(prefix string, fn WalkMutFn) {
+	n, _ := t.findPrefix(prefix)
+	if n == nil {
+		return
+	}
+
+	var deleted map[string]struct{}
+	c := &Cursor{}
+	walkLeaves(n, func(l *leafNode) bool {
+		*c = Cursor{leaf: l}
+		stop := fn(c)
+		if c.deleted {
+			if deleted == nil {
+				deleted = make(map[string]struct{})
+			}
+			deleted[l.key] = struct{}{}
+		}
+		return stop
+	})
+
+	if len(deleted) > 0 {
+		t.DeleteFunc(prefix, func(k string, v interface{}) bool {
+			_, ok := deleted[k]
+			return ok
+		})
+	}
+}
+
+// walkLeaves is like recursiveWalk, but hands out the leaves
+// themselves so that they can be modified
+func walkLeaves(n *node, fn func(l *leafNode) bool) bool {
+	if n.leaf != nil && fn(n.leaf) {
+		return true
+	}
+	for _, e := range n.edges {
+		if walkLeaves(e.node, fn) {
+			return true
+		}
+	}
+	return false
+}
+
+// UpdatePrefix is used to rewrite the value of every entry
+// under a prefix while holding the write lock
+func (t *ConcurrentTree) UpdatePrefix(prefix string, fn func(k string, v interface{}) interface{}) int {
+	t.Lock()
+	defer t.Unlock()
+	return t.Tree.UpdatePrefix(prefix, fn)
+}
+
+// UpdatePrefix is used to rewrite the value of every entry under
+// a prefix with the value returned by fn. Values are replaced in
+// place during a single traversal. Returns how many entries were
+// updated.
+func (t *Tree) UpdatePrefix(prefix string, fn func(k string, v interface{}) interface{}) int {
+	n, _ := t.findPrefix(prefix)
+	if n == nil {
+		return 0
+	}
+	updated := 0
+	walkLeaves(n, func(l *leafNode) bool {
+		l.val = fn(l.key, l.val)
+		updated++
+		return false
+	})
+	return updated
+}
@@ -0,0 +1,110 @@
+package radix
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWalkMut(t *testing.T) {
+	keys := []string{"", "foo", "foo/bar", "foo/bar/baz", "foo/baz", "foo/zip", "zip"}
+	for _, pool := range []bool{false, true} {
+		var opts []Option
+		if pool {
+			opts = append(opts, WithNodePool(0))
+		}
+		r := New(opts...)
+		for i, k := range keys {
+			r.Insert(k, i)
+		}
+
+		// Delete the odd entries and double the even ones
+		visited := []string{}
+		r.WalkMut("foo", func(c *Cursor) bool {
+			visited = append(visited, c.Key())
+			v := c.Value().(int)
+			if v%2 == 1 {
+				c.Delete()
+				c.Set(-1)
+			} else {
+				c.Set(v * 2)
+			}
+			return false
+		})
+		if exp := keys[1:6]; !reflect.DeepEqual(visited, exp) {
+			t.Fatalf("mis-match: %v %v", visited, exp)
+		}
+		if err := r.Validate(); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		exp := map[string]interface{}{"": 0, "foo/bar": 4, "foo/baz": 8, "zip": 6}
+		if out := r.ToMap(); !reflect.DeepEqual(out, exp) {
+			t.Fatalf("mis-match: %v %v", out, exp)
+		}
+	}
+
+	// Stopping early still applies the deletions made so far
+	r := New()
+	for i, k := range keys {
+		r.Insert(k, i)
+	}
+	r.WalkMut("", func(c *Cursor) bool {
+		c.Delete()
+		return c.Key() == "foo/bar"
+	})
+	if err := r.Validate(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	exp := map[string]interface{}{"foo/bar/baz": 3, "foo/baz": 4, "foo/zip": 5, "zip": 6}
+	if out := r.ToMap(); !reflect.DeepEqual(out, exp) {
+		t.Fatalf("mis-match: %v %v", out, exp)
+	}
+}
+
+func TestConcurrentTreeWalkMut(t *testing.T) {
+	r := NewConcurrentTree()
+	for i, k := range []string{"a", "ab", "abc", "b"} {
+		r.Insert(k, i)
+	}
+	r.WalkMut("a", func(c *Cursor) bool {
+		if c.Key() == "ab" {
+			c.Delete()
+		} else {
+			c.Set(c.Value().(int) + 10)
+		}
+		return false
+	})
+	if err := r.Validate(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	exp := map[string]interface{}{"a": 10, "abc": 12, "b": 3}
+	if out := r.ToMap(); !reflect.DeepEqual(out, exp) {
+		t.Fatalf("mis-match: %v %v", out, exp)
+	}
+}
+
+func TestUpdatePrefix(t *testing.T) {
+	r := NewConcurrentTree()
+	for i, k := range []string{"", "cnt/a", "cnt/b", "cnt/b/c", "cntx", "zip"} {
+		r.Insert(k, i)
+	}
+	updated := r.UpdatePrefix("cnt/", func(k string, v interface{}) interface{} {
+		return v.(int) * 10
+	})
+	if updated != 3 {
+		t.Fatalf("bad update: %v", updated)
+	}
+	exp := map[string]interface{}{"": 0, "cnt/a": 10, "cnt/b": 20, "cnt/b/c": 30, "cntx": 4, "zip": 5}
+	if out := r.ToMap(); !reflect.DeepEqual(out, exp) {
+		t.Fatalf("mis-match: %v %v", out, exp)
+	}
+
+	if updated := r.UpdatePrefix("nope", nil); updated != 0 {
+		t.Fatalf("bad update: %v", updated)
+	}
+	if updated := r.UpdatePrefix("", func(k string, v interface{}) interface{} { return k }); updated != 6 {
+		t.Fatalf("bad update: %v", updated)
+	}
+	if v, _ := r.Get("cntx"); v != "cntx" {
+		t.Fatalf("bad value: %v", v)
+	}
+}